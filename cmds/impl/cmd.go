@@ -1,42 +1,215 @@
 package impl
 
 import (
+	"errors"
 	"fmt"
+	"go/types"
 	"io"
+	"io/fs"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"unicode"
 
+	"github.com/go-juicedev/juice"
 	"github.com/go-juicedev/juicecli/cmds/impl/internal"
+	astlite "github.com/go-juicedev/juicecli/internal/ast"
 	"github.com/go-juicedev/juicecli/internal/command"
+	"github.com/go-juicedev/juicecli/internal/diff"
+	"github.com/go-juicedev/juicecli/internal/mapper"
+	"github.com/go-juicedev/juicecli/internal/module"
+	"github.com/go-juicedev/juicecli/internal/namespace"
 	"github.com/spf13/cobra"
 )
 
-func do(targetType, namespace, output, cfg, version string) error {
-	parser := internal.NewParser(targetType).WithNamespace(namespace).WithOutput(output).WithConfig(cfg)
+type options struct {
+	types     []string
+	patterns  []string
+	namespace string
+	output    string
+	pkg       string
+	cfg       string
+	version   string
+	check     bool
+	partial   bool
+	sql       bool
+}
+
+// batch reports whether the implementations of the targets found should be written next to their interfaces,
+// which is the case when more than one target is found, or when packages are given without the output.
+func (o options) batch(targets int) bool {
+	return targets > 1 || (len(o.patterns) > 0 && o.output == "")
+}
+
+// target is an interface to generate implementation for.
+type target struct {
+	dir  string
+	node *module.InterfaceNode
+	// types is the type-checked package of the interface, nil if it can not be loaded.
+	types *types.Package
+}
+
+// iface returns the interface of the target.
+func (t target) iface() *astlite.Interface {
+	node := t.node
+	return &astlite.Interface{InterfaceType: node.Type, TypeParams: node.TypeParams, Name: node.Name, Dir: t.dir, File: node.File, Doc: node.Doc, Types: t.types}
+}
+
+func do(opts options) error {
+	parser := internal.NewParser("").WithConfig(opts.cfg)
 	config, err := parser.Config()
 	if err != nil {
 		return err
 	}
-	iface, file, err := parser.TypeInterface()
+	index, err := parser.Mappers()
 	if err != nil {
 		return err
 	}
-	namespace, err = parser.Namespace()
+	targets, err := findTargets(index, opts)
 	if err != nil {
 		return err
 	}
-	implement, err := internal.NewImplement(file, iface, config, namespace, version, targetType, targetType+"Impl")
+	if len(targets) == 0 {
+		return errors.New("no interface found to generate implementation for")
+	}
+	loadTypes(targets)
+	batch := opts.batch(len(targets))
+	if batch && opts.output != "" {
+		return errors.New("output can not be specified when generating multiple implementations")
+	}
+	if opts.check && !batch && opts.output == "" {
+		return errors.New("output is required in check mode")
+	}
+	if len(targets) > 1 && opts.namespace != "" {
+		return errors.New("namespace can not be specified when generating multiple implementations")
+	}
+	var stale, files int
+	// rowCountErrors are the packages which require the RowCountErrorFile, by their directories
+	rowCountErrors := make(map[string]string)
+	for _, t := range targets {
+		output := opts.output
+		if batch {
+			dir := t.dir
+			if opts.pkg != "" {
+				dir = opts.pkg
+			}
+			output = filepath.Join(dir, implFileName(t.node.Name))
+		}
+		ns := opts.namespace
+		if ns == "" {
+			if ns, err = namespace.OfInterface(t.iface()); err != nil {
+				return fmt.Errorf("%s: %w", t.node.Name, err)
+			}
+		}
+		parser := internal.NewParser(t.node.Name).WithDir(t.dir).WithNamespace(ns).WithOutput(output).WithPackage(opts.pkg)
+		implement, reader, err := generate(parser, t, config, index, opts)
+		if err != nil {
+			return fmt.Errorf("%s: %w", t.node.Name, err)
+		}
+		if implement.RowCountError() {
+			rowCountErrors[parser.OutputDir()] = implement.Package()
+		}
+		files++
+		if !opts.check {
+			if err = write(parser, reader); err != nil {
+				return fmt.Errorf("%s: %w", t.node.Name, err)
+			}
+			continue
+		}
+		upToDate, err := check(output, reader)
+		if err != nil {
+			return fmt.Errorf("%s: %w", t.node.Name, err)
+		}
+		if !upToDate {
+			stale++
+		}
+	}
+	for _, dir := range slices.Sorted(maps.Keys(rowCountErrors)) {
+		output := filepath.Join(dir, internal.RowCountErrorFile)
+		reader, err := internal.NewGenerator(internal.NewRowCountErrorFile(rowCountErrors[dir])).Generate()
+		if err != nil {
+			return err
+		}
+		files++
+		if !opts.check {
+			// nothing is written without output, the shared file follows the implementation to stdout
+			if !batch && opts.output == "" {
+				output = ""
+			}
+			if err = write(internal.NewParser("").WithOutput(output), reader); err != nil {
+				return err
+			}
+			continue
+		}
+		upToDate, err := check(output, reader)
+		if err != nil {
+			return err
+		}
+		if !upToDate {
+			stale++
+		}
+	}
+	if stale > 0 {
+		return fmt.Errorf("%d of %d generated files are out of date", stale, files)
+	}
+	return nil
+}
+
+func generate(parser *internal.Parser, t target, config juice.Configuration, index *mapper.Index, opts options) (internal.Implement, io.Reader, error) {
+	namespace, err := parser.Namespace()
 	if err != nil {
-		return err
+		return nil, nil, err
+	}
+	iface := t.iface()
+	pkg, err := parser.Package()
+	if err != nil {
+		return nil, nil, err
+	}
+	// the implementation is generated into another package, refer to the interface by its package
+	if pkg != "" {
+		if t.node.File.Name.Name == "main" {
+			return nil, nil, errors.New("interface declared in package main can not be implemented in another package")
+		}
+		importPath, err := module.ImportPath(t.dir)
+		if err != nil {
+			return nil, nil, err
+		}
+		iface = iface.Qualify(t.node.File.Name.Name, importPath)
+	}
+	implement, err := internal.NewImplement(iface, config, index, namespace, opts.version, t.node.Name+"Impl", pkg, parser.OutputDir(), opts.partial, opts.sql)
+	if err != nil {
+		return nil, nil, err
 	}
 	reader, err := internal.NewGenerator(implement).Generate()
 	if err != nil {
-		return err
+		return nil, nil, err
+	}
+	// the summary goes to stderr, since the generated code may be written to stdout
+	if missing := implement.Missing(); len(missing) > 0 {
+		_, _ = fmt.Fprintf(os.Stderr, "%s: stubs generated for statements not defined:\n", t.node.Name)
+		for _, name := range missing {
+			_, _ = fmt.Fprintf(os.Stderr, "\t%s\n", name)
+		}
 	}
+	if deprecated := implement.Deprecated(); len(deprecated) > 0 {
+		_, _ = fmt.Fprintf(os.Stderr, "%s: methods generated from deprecated statements:\n", t.node.Name)
+		for _, method := range deprecated {
+			_, _ = fmt.Fprintf(os.Stderr, "\t%s\n", method)
+		}
+	}
+	return implement, reader, nil
+}
+
+func write(parser *internal.Parser, reader io.Reader) error {
 	writer, err := parser.Output()
 	if err != nil {
 		return err
 	}
 	defer func() {
-		if closer, ok := writer.(io.Closer); ok {
+		// stdout is left open for the files written after
+		if closer, ok := writer.(io.Closer); ok && writer != os.Stdout {
 			_ = closer.Close()
 		}
 	}()
@@ -44,27 +217,144 @@ func do(targetType, namespace, output, cfg, version string) error {
 	return err
 }
 
+// check compares the generated code with the existing output file and prints their unified diff.
+// The header line is ignored since it records the command line which generated the code,
+// and a missing output file is compared as an empty one.
+func check(output string, reader io.Reader) (bool, error) {
+	generated, err := io.ReadAll(reader)
+	if err != nil {
+		return false, err
+	}
+	existing, err := os.ReadFile(output)
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return false, err
+	}
+	result := diff.Unified(output, output+" (generated)", internal.StripHeader(string(existing)), internal.StripHeader(string(generated)))
+	if result == "" {
+		return true, nil
+	}
+	fmt.Print(result)
+	return false, nil
+}
+
+// findTargets finds the interfaces in the packages matched by the patterns.
+// If no type is specified, every interface whose namespace is declared by a mapper is returned.
+func findTargets(index *mapper.Index, opts options) ([]target, error) {
+	patterns := opts.patterns
+	if len(patterns) == 0 {
+		patterns = []string{"."}
+	}
+	dirs, err := module.ResolvePackageDirs(patterns...)
+	if err != nil {
+		return nil, err
+	}
+	found := make(map[string]bool, len(opts.types))
+	for _, typename := range opts.types {
+		found[typename] = false
+	}
+	var targets []target
+	for _, dir := range dirs {
+		nodes, err := module.FindInterfaceNodes(dir)
+		if err != nil {
+			return nil, err
+		}
+		for _, node := range nodes {
+			if len(opts.types) > 0 {
+				if _, ok := found[node.Name]; ok {
+					found[node.Name] = true
+					targets = append(targets, target{dir: dir, node: node})
+				}
+				continue
+			}
+			t := target{dir: dir, node: node}
+			ns, err := namespace.OfInterface(t.iface())
+			if err != nil {
+				return nil, err
+			}
+			if _, ok := index.Mapper(ns); ok {
+				targets = append(targets, t)
+			}
+		}
+	}
+	for _, typename := range opts.types {
+		if !found[typename] {
+			return nil, fmt.Errorf("can not find interface %s", typename)
+		}
+	}
+	return targets, nil
+}
+
+// loadTypes loads the types of the packages of the targets, which are shared by the interfaces of the same package.
+// The generator falls back to the spelling of the types if they can not be loaded, e.g. without the go command.
+func loadTypes(targets []target) {
+	packages := make(map[string]*types.Package)
+	for index, t := range targets {
+		pkg, ok := packages[t.dir]
+		if !ok {
+			var err error
+			if pkg, err = module.LoadTypes(t.dir); err != nil {
+				_, _ = fmt.Fprintf(os.Stderr, "%s: can not load types, the types are recognized by their spelling: %v\n", t.dir, err)
+			}
+			packages[t.dir] = pkg
+		}
+		targets[index].types = pkg
+	}
+}
+
+// implFileName returns the file name of the generated implementation,
+// e.g. UserRepository => user_repository_impl.go
+func implFileName(typename string) string {
+	var builder strings.Builder
+	runes := []rune(typename)
+	for i, r := range runes {
+		if unicode.IsUpper(r) {
+			if i > 0 && (unicode.IsLower(runes[i-1]) || (i+1 < len(runes) && unicode.IsLower(runes[i+1]))) {
+				builder.WriteByte('_')
+			}
+			r = unicode.ToLower(r)
+		}
+		builder.WriteRune(r)
+	}
+	builder.WriteString("_impl.go")
+	return builder.String()
+}
+
+// splitTypes splits the comma separated type names.
+func splitTypes(value string) []string {
+	var types []string
+	for _, typename := range strings.Split(value, ",") {
+		if typename = strings.TrimSpace(typename); typename != "" {
+			types = append(types, typename)
+		}
+	}
+	return types
+}
+
 func NewCommand() *cobra.Command {
 	typeArg := command.Arg{
 		Name:      "type",
 		ShortHand: "t",
-		Required:  true,
-		Usage:     "The interface type name to generate implementation for (e.g. UserRepository)",
+		Usage:     "The comma separated interface type names to generate implementation for (e.g. UserRepository,OrderRepository). If not specified, every interface declared by a mapper will be generated",
 	}
 	namespaceArg := command.Arg{
 		Name:      "namespace",
 		ShortHand: "n",
-		Usage:     "The package name for the generated implementation (e.g. repository). If not specified, it will be auto-generated",
+		Usage:     "The mapper namespace of the interface (e.g. repository.UserRepository). If not specified, it will be auto-generated from the package of the interface",
+	}
+	packageArg := command.Arg{
+		Name:      "package",
+		ShortHand: "p",
+		Usage:     "The directory of the package to generate the implementation into (e.g. infra/repo). If not specified, the package of the interface will be used",
 	}
 	outputArg := command.Arg{
 		Name:      "output",
 		ShortHand: "o",
-		Usage:     "The output file path for the generated implementation. If not specified, output will be written to stdout",
+		Usage:     "The output file path for the generated implementation. If not specified, output will be written to stdout, or to <type>_impl.go next to the interface when packages are given or multiple implementations are generated",
 	}
 	configArg := command.Arg{
 		Name:      "config",
 		ShortHand: "c",
-		Usage:     "The configuration file path. If not specified, it will search for juice.xml, config/juice.xml, config.xml, or config/config.xml",
+		Usage:     "The configuration file path. If not specified, it will search for " + strings.Join(mapper.DefaultConfigFiles(), ", ") + " in order",
 	}
 	versionArg := command.Arg{
 		Name:      "version",
@@ -72,27 +362,74 @@ func NewCommand() *cobra.Command {
 		Usage:     "The version of juice framework to target. Default is the v1.",
 		Value:     "v1",
 	}
+	checkArg := command.Arg{
+		Name:  "check",
+		Usage: "Check whether the generated implementations are up to date without writing them. The differences are printed as unified diff, and the command exits with non-zero status if any implementation is out of date",
+		Bool:  true,
+	}
+	partialArg := command.Arg{
+		Name:  "partial",
+		Usage: "Generate stubs returning an error wrapping juice.ErrNoStatementFound for the methods whose statement is not defined, instead of failing",
+		Bool:  true,
+	}
+	sqlArg := command.Arg{
+		Name:  "sql",
+		Usage: "Append the SQL of the statements to the doc comments of the generated methods",
+		Bool:  true,
+	}
 	args := []command.Arg{
 		typeArg,
 		namespaceArg,
+		packageArg,
 		outputArg,
 		configArg,
 		versionArg,
+		checkArg,
+		partialArg,
+		sqlArg,
 	}
 	cmd := command.NewCommand("impl", args...)
+	cmd.Use = "impl [packages]"
 	cmd.Short = "Generate implementation for an interface"
-	cmd.Long = "Generate implementation for an interface based on configuration. It supports customizing the implementation through XML configuration files."
+	cmd.Long = "Generate implementation for an interface based on configuration. It supports customizing the implementation through XML configuration files.\n\n" +
+		"Packages are directories, and a directory ending with /... matches all its subdirectories. " +
+		"When more than one interface is found, or packages are given without --output, one <type>_impl.go file is written next to each interface, or into the package specified by --package."
 	cmd.Example = "  juicecli impl --type UserRepository\n" +
-		"  juicecli impl --type UserRepository --namespace repository --output user_repository.go\n" +
-		"  juicecli impl --type UserRepository --config custom.xml"
+		"  juicecli impl --type UserRepository --namespace repository.UserRepository --output user_repository.go\n" +
+		"  juicecli impl --type UserRepository --package ../infra/repo --output ../infra/repo/user_repository.go\n" +
+		"  juicecli impl --type UserRepository --config custom.xml\n" +
+		"  juicecli impl --type UserRepository,OrderRepository\n" +
+		"  juicecli impl ./...\n" +
+		"  juicecli impl --partial ./...\n" +
+		"  juicecli impl --sql ./...\n" +
+		"  juicecli impl --check ./..."
 	cmd.Run = func(cmd *cobra.Command, args []string) {
-		targetType, _ := cmd.Flags().GetString(typeArg.Name)
+		types, _ := cmd.Flags().GetString(typeArg.Name)
 		namespace, _ := cmd.Flags().GetString(namespaceArg.Name)
+		pkg, _ := cmd.Flags().GetString(packageArg.Name)
 		output, _ := cmd.Flags().GetString(outputArg.Name)
 		config, _ := cmd.Flags().GetString(configArg.Name)
 		version, _ := cmd.Flags().GetString(versionArg.Name)
-		if err := do(targetType, namespace, output, config, version); err != nil {
+		check, _ := cmd.Flags().GetBool(checkArg.Name)
+		partial, _ := cmd.Flags().GetBool(partialArg.Name)
+		sql, _ := cmd.Flags().GetBool(sqlArg.Name)
+		opts := options{
+			types:     splitTypes(types),
+			patterns:  args,
+			namespace: namespace,
+			pkg:       pkg,
+			output:    output,
+			cfg:       config,
+			version:   version,
+			check:     check,
+			partial:   partial,
+			sql:       sql,
+		}
+		if err := do(opts); err != nil {
 			fmt.Println(err)
+			if check {
+				os.Exit(1)
+			}
 		}
 	}
 	return cmd
@@ -0,0 +1,154 @@
+package internal
+
+import (
+	"fmt"
+	"go/ast"
+	"go/build"
+	"go/types"
+	"reflect"
+	"strconv"
+	"strings"
+	"unicode"
+
+	sqllib "github.com/go-juicedev/juice/sql"
+	astlite "github.com/go-juicedev/juicecli/internal/ast"
+	"github.com/go-juicedev/juicecli/internal/module"
+)
+
+// findColumnField returns the name of the struct field which the column is mapped to by its column tag.
+// The fields of the embedded structs are searched too, since they are promoted.
+// The dir and imports are of the package and file which refer to the struct type.
+func findColumnField(expr ast.Expr, column, dir string, imports []*ast.ImportSpec) (string, error) {
+	if star, ok := expr.(*ast.StarExpr); ok {
+		expr = star.X
+	}
+	var name string
+	switch t := expr.(type) {
+	case *ast.Ident:
+		name = t.Name
+	case *ast.SelectorExpr:
+		ident, ok := t.X.(*ast.Ident)
+		if !ok {
+			return "", fmt.Errorf("unsupported type %s", types.ExprString(expr))
+		}
+		pkgDir, err := importDir(ident.Name, dir, imports)
+		if err != nil {
+			return "", err
+		}
+		dir, name = pkgDir, t.Sel.Name
+	default:
+		return "", fmt.Errorf("unsupported type %s", types.ExprString(expr))
+	}
+	node, file, err := module.FindTypeNode(dir, name)
+	if err != nil {
+		return "", err
+	}
+	structType, ok := node.(*ast.StructType)
+	if !ok {
+		return "", fmt.Errorf("%s is not a struct", name)
+	}
+	for _, field := range structType.Fields.List {
+		var tag string
+		if field.Tag != nil {
+			value, err := strconv.Unquote(field.Tag.Value)
+			if err != nil {
+				return "", err
+			}
+			tag = reflect.StructTag(value).Get(sqllib.ColumnTagName())
+		}
+		if len(field.Names) == 0 {
+			if tag != "" {
+				continue
+			}
+			if fieldName, err := findColumnField(field.Type, column, dir, file.Imports); err == nil {
+				return fieldName, nil
+			}
+			continue
+		}
+		if tag == column {
+			return field.Names[0].Name, nil
+		}
+	}
+	return "", fmt.Errorf("no field of %s is mapped to column %s", name, column)
+}
+
+// findKeyField returns the field which the generated key is set to, found the way juice finds it.
+// The key property is a path of fields separated by dots, each of which is the name of the field if it is exported,
+// otherwise the column tag of the field. Without the key property, the field is the one tagged by autoincr:"true".
+func findKeyField(t types.Type, keyProperty string, qualifier types.Qualifier) (*types.Var, error) {
+	if keyProperty == "" {
+		field, ok := findTaggedField(t, "autoincr", "true")
+		if !ok {
+			return nil, fmt.Errorf("no field of %s is tagged autoincr:\"true\"", types.TypeString(t, qualifier))
+		}
+		return field, nil
+	}
+	var field *types.Var
+	for _, property := range strings.Split(keyProperty, ".") {
+		if property == "" {
+			return nil, fmt.Errorf("invalid key property %s", keyProperty)
+		}
+		if _, ok := t.Underlying().(*types.Struct); !ok {
+			return nil, fmt.Errorf("%s is not a struct", types.TypeString(t, qualifier))
+		}
+		var ok bool
+		if unicode.IsUpper(rune(property[0])) {
+			object, _, _ := types.LookupFieldOrMethod(t, true, nil, property)
+			if field, ok = object.(*types.Var); !ok {
+				return nil, fmt.Errorf("no field of %s is named %s", types.TypeString(t, qualifier), property)
+			}
+		} else if field, ok = findTaggedField(t, sqllib.ColumnTagName(), property); !ok {
+			return nil, fmt.Errorf("no field of %s is mapped to column %s", types.TypeString(t, qualifier), property)
+		}
+		t = field.Type()
+	}
+	return field, nil
+}
+
+// findTaggedField returns the field of the struct type whose tag of the name has the value.
+// Like juice, the fields of the embedded structs and the untagged struct fields are searched too.
+func findTaggedField(t types.Type, name, value string) (*types.Var, bool) {
+	path, ok := findTaggedPath(t, name, value)
+	if !ok {
+		return nil, false
+	}
+	return path[len(path)-1], true
+}
+
+// findTaggedPath returns the path of the fields to the field found by findTaggedField,
+// whose names select the field from a value of the struct type.
+func findTaggedPath(t types.Type, name, value string) ([]*types.Var, bool) {
+	structType, ok := t.Underlying().(*types.Struct)
+	if !ok {
+		return nil, false
+	}
+	for i := range structType.NumFields() {
+		field := structType.Field(i)
+		tag := reflect.StructTag(structType.Tag(i)).Get(name)
+		if tag == value {
+			return []*types.Var{field}, true
+		}
+		if _, ok = field.Type().Underlying().(*types.Struct); ok && (field.Embedded() || tag == "") {
+			if path, ok := findTaggedPath(field.Type(), name, value); ok {
+				return append([]*types.Var{field}, path...), true
+			}
+		}
+	}
+	return nil, false
+}
+
+// importDir returns the directory of the package imported with the name.
+func importDir(name, dir string, imports []*ast.ImportSpec) (string, error) {
+	for _, spec := range imports {
+		imp := &astlite.Import{ImportSpec: spec}
+		if imp.Usage() != name {
+			continue
+		}
+		pkg, err := build.Import(imp.UnQuote(), dir, build.FindOnly)
+		if err != nil {
+			return "", err
+		}
+		return pkg.Dir, nil
+	}
+	return "", fmt.Errorf("can not find import of %s", name)
+}
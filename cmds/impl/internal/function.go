@@ -20,11 +20,23 @@ import (
 	"errors"
 	"fmt"
 	stdast "go/ast"
+	"go/token"
+	"go/types"
+	"go/version"
+	"html"
+	"regexp"
+	"slices"
+	"strconv"
 	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
 
 	"github.com/go-juicedev/juice"
 	sqllib "github.com/go-juicedev/juice/sql"
 	"github.com/go-juicedev/juicecli/internal/ast"
+	"github.com/go-juicedev/juicecli/internal/mapper"
+	"github.com/go-juicedev/juicecli/internal/module"
 )
 
 type FunctionBodyMaker interface {
@@ -54,10 +66,34 @@ type Function struct {
 	receiver string
 	body     string
 	typename string
+	// statementName is the full name of the statement,
+	// only set when juice can not find the statement by the method value.
+	statementName string
+	// iface is the interface which declares the function.
+	iface *ast.Interface
+	// imports are the extra imports required by the body.
+	imports ast.ImportGroup
+	// checksRowCount reports whether the body returns errUnexpectedRowCount.
+	checksRowCount bool
+	// timeout is the duration expression of the `timeout` attribute of the statement, empty if not enforced.
+	timeout string
+	// timeoutRows is the type of the rows returned by the function, which are wrapped to cancel the timeout when closed.
+	timeoutRows string
+	// allowed are the params whose values are checked before executing the statement.
+	allowed []allowedParam
+	// source is the statement declared in the mapper file, nil if unknown.
+	source *mapper.Statement
+	// collections are the slice params iterated by the foreach elements of the statement.
+	collections []string
+	// sql is the raw SQL of the statement appended to the doc comment, empty if not appended.
+	sql string
+	// deprecated is the message of the `deprecated` attribute of the statement, empty if not deprecated.
+	deprecated string
 }
 
 func (f *Function) String() string {
 	var builder strings.Builder
+	builder.WriteString(f.comment())
 	builder.WriteString(fmt.Sprintf("func (%s %s) %s", f.receiverAlias(), f.receiver, f.method.Signature()))
 	builder.WriteString(" {")
 	if f.body == "" {
@@ -70,10 +106,299 @@ func (f *Function) String() string {
 	return builder.String()
 }
 
+// comment returns the doc comment of the method copied from the interface,
+// followed by the SQL of the statement as a code block if it is appended,
+// and the deprecation paragraph if the statement is deprecated.
+func (f *Function) comment() string {
+	doc := f.method.Comment()
+	if f.sql == "" && f.deprecated == "" {
+		return doc
+	}
+	var builder strings.Builder
+	builder.WriteString(doc)
+	if f.sql != "" {
+		if doc == "" {
+			builder.WriteString(fmt.Sprintf("// %s executes:\n", f.Name()))
+		}
+		builder.WriteString("//\n")
+		for _, line := range strings.Split(f.sql, "\n") {
+			if line == "" {
+				builder.WriteString("//\n")
+				continue
+			}
+			builder.WriteString("//\t" + line + "\n")
+		}
+	}
+	if f.deprecated != "" {
+		if builder.Len() > 0 {
+			builder.WriteString("//\n")
+		}
+		builder.WriteString(fmt.Sprintf("// Deprecated: %s\n", f.deprecated))
+	}
+	return builder.String()
+}
+
+// formatSQL trims the raw SQL of a statement to be shown in comments.
+// The XML escapes and CDATA sections are decoded, see unescapeXML,
+// then the blank lines around it and the indentation common to its lines are removed,
+// except for the first line which follows the start tag of the statement.
+func formatSQL(raw string) string {
+	lines := strings.Split(strings.ReplaceAll(unescapeXML(raw), "\r\n", "\n"), "\n")
+	for index, line := range lines {
+		lines[index] = strings.TrimRight(line, " \t")
+	}
+	first := strings.TrimSpace(lines[0])
+	lines = lines[1:]
+	indent := ""
+	for index, line := range slices.DeleteFunc(slices.Clone(lines), func(line string) bool { return line == "" }) {
+		lineIndent := line[:len(line)-len(strings.TrimLeft(line, " \t"))]
+		if index == 0 {
+			indent = lineIndent
+			continue
+		}
+		for !strings.HasPrefix(lineIndent, indent) {
+			indent = indent[:len(indent)-1]
+		}
+	}
+	for index, line := range lines {
+		lines[index] = strings.TrimPrefix(line, indent)
+	}
+	if first != "" {
+		lines = append([]string{first}, lines...)
+	}
+	for len(lines) > 0 && lines[0] == "" {
+		lines = lines[1:]
+	}
+	for len(lines) > 0 && lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+	return strings.Join(lines, "\n")
+}
+
 func (f *Function) receiverAlias() string {
 	return strings.ToLower(f.receiver[:1])
 }
 
+// statement returns the expression which identifies the statement of the function.
+func (f *Function) statement() string {
+	if f.statementName != "" {
+		return strconv.Quote(f.statementName)
+	}
+	return fmt.Sprintf("%s(%s).%s", f.typename, f.receiverAlias(), f.Name())
+}
+
+// beginTimeout writes the statement which replaces the context with the one canceled after the timeout.
+// The caller is responsible for calling cancel.
+func (f *Function) beginTimeout(builder *funcBodyWriter, tab int) {
+	f.imports = append(f.imports,
+		&ast.Import{ImportSpec: extraImport.Imports[2]},
+		&ast.Import{ImportSpec: extraImport.Imports[5]},
+	)
+	ctx := f.Params().NameAt(ast.ParamPrefix, 0)
+	builder.FTabWrite(tab, "%s, cancel := context.WithTimeout(%s, %s)", ctx, ctx, f.timeout)
+}
+
+// unescapeXML decodes the character references of the raw XML, e.g. &lt; for <,
+// and strips the markers of the CDATA sections, whose text is kept as is.
+func unescapeXML(raw string) string {
+	var builder strings.Builder
+	for {
+		start := strings.Index(raw, "<![CDATA[")
+		if start < 0 {
+			break
+		}
+		end := strings.Index(raw[start:], "]]>")
+		if end < 0 {
+			break
+		}
+		builder.WriteString(html.UnescapeString(raw[:start]))
+		builder.WriteString(raw[start+len("<![CDATA[") : start+end])
+		raw = raw[start+end+len("]]>"):]
+	}
+	builder.WriteString(html.UnescapeString(raw))
+	return builder.String()
+}
+
+// allowPrefix is the prefix of the attributes which declare the allowed values of the params,
+// e.g. allow-sort="created_at,name" for ${sort}, since juice does not allow unknown elements in statements.
+const allowPrefix = "allow-"
+
+// allowedParam is a param whose value must be one of the values.
+type allowedParam struct {
+	name   string
+	values []string
+}
+
+// parseAllowed parses the allowed values of the params declared by the statement.
+// Only the string params of the function substituted by ${} can be checked,
+// and the last result of the function must be error to report the invalid value.
+func (f *Function) parseAllowed(statement juice.Statement) error {
+	params := f.Params()
+	for index, param := range params {
+		if index == 0 || param.Name() == "" {
+			continue
+		}
+		allow := statement.Attribute(allowPrefix + param.Name())
+		if allow == "" {
+			continue
+		}
+		if !isString(param) {
+			return fmt.Errorf("`%s` `%s%s` requires %s to be a string", statement.ID(), allowPrefix, param.Name(), param.Name())
+		}
+		// the params bound by #{} are passed as arguments, which need no check
+		if f.source != nil && !regexp.MustCompile(`\$\{\s*`+regexp.QuoteMeta(param.Name())+`\s*}`).MatchString(f.source.SQL) {
+			return fmt.Errorf("`%s` `%s%s` requires %s to be used by ${%s} in the statement", statement.ID(), allowPrefix, param.Name(), param.Name(), param.Name())
+		}
+		results := f.Results()
+		if len(results) == 0 || !isError(results[len(results)-1]) {
+			return fmt.Errorf("`%s` `%s%s` requires the last result of %s to be error", statement.ID(), allowPrefix, param.Name(), f.Name())
+		}
+		var values []string
+		for _, value := range strings.Split(allow, ",") {
+			if value = strings.TrimSpace(value); value != "" {
+				values = append(values, value)
+			}
+		}
+		if len(values) == 0 {
+			return fmt.Errorf("`%s` `%s%s` must list the allowed values separated by comma", statement.ID(), allowPrefix, param.Name())
+		}
+		f.allowed = append(f.allowed, allowedParam{name: param.Name(), values: values})
+	}
+	return nil
+}
+
+// writeAllowed writes the checks of the allowed params, which return an error if any value is not allowed.
+func (f *Function) writeAllowed(builder *funcBodyWriter) {
+	if len(f.allowed) == 0 {
+		return
+	}
+	f.imports = append(f.imports, &ast.Import{ImportSpec: extraImport.Imports[1]})
+	results := f.Results()
+	for _, param := range f.allowed {
+		values := make([]string, 0, len(param.values))
+		for _, value := range param.values {
+			values = append(values, strconv.Quote(value))
+		}
+		builder.FWrite("switch %s {", param.name)
+		builder.FWrite("case %s:", strings.Join(values, ", "))
+		builder.FWrite("default:")
+		builder.FTabWrite(2, "%s = fmt.Errorf(\"%%s: %%s %%q is not allowed\", %q, %q, %s)",
+			results.NameAt(ast.ResultPrefix, len(results)-1), f.Name(), param.name, param.name)
+		builder.FTabWrite(2, "return")
+		builder.FWrite("}")
+	}
+}
+
+// parseCollections parses the slice params used as the collection of the foreach elements of the statement,
+// which are rendered as invalid SQL like `IN ()` if the slices are empty.
+func (f *Function) parseCollections() error {
+	if f.source == nil {
+		return nil
+	}
+	collections, err := f.source.ForeachCollections()
+	if err != nil {
+		return err
+	}
+	var names []string
+	for index, param := range f.Params() {
+		if index == 0 || !slices.Contains(collections, param.Name()) || slices.Contains(names, param.Name()) {
+			continue
+		}
+		if isSlice(param) {
+			names = append(names, param.Name())
+		}
+	}
+	f.collections = names
+	return nil
+}
+
+// writeEmptyCollections writes the check which returns early if any of the collections is empty.
+func (f *Function) writeEmptyCollections(builder *funcBodyWriter, ret string) {
+	if len(f.collections) == 0 {
+		return
+	}
+	conditions := make([]string, 0, len(f.collections))
+	for _, name := range f.collections {
+		conditions = append(conditions, fmt.Sprintf("len(%s) == 0", name))
+	}
+	builder.FWrite("if %s {", strings.Join(conditions, " || "))
+	builder.FTabWrite(2, "%s", ret)
+	builder.FWrite("}")
+}
+
+// writeNilChecks writes the checks which return an error if the pointers to rows dereferenced by the statement are nil.
+// Nullable pointers, e.g. *int64 or *time.Time, are not checked, whose nil is bound as NULL.
+// The only param besides context.Context is the root of the statement, which is dereferenced by any parameter,
+// otherwise the param is dereferenced by its fields, e.g. #{user.Name}.
+func (f *Function) writeNilChecks(builder *funcBodyWriter) {
+	results := f.Results()
+	if f.source == nil || len(results) == 0 || !isError(results[len(results)-1]) {
+		return
+	}
+	params := f.Params()
+	for index, param := range params {
+		if index == 0 || param.Name() == "" {
+			continue
+		}
+		if !isRowPointer(param) {
+			continue
+		}
+		var dereferenced bool
+		if len(params) == 2 {
+			dereferenced = strings.Contains(f.source.SQL, "#{") || strings.Contains(f.source.SQL, "${")
+		} else {
+			dereferenced = regexp.MustCompile(`\b` + regexp.QuoteMeta(param.Name()) + `\.`).MatchString(f.source.SQL)
+		}
+		if !dereferenced {
+			continue
+		}
+		f.imports = append(f.imports, &ast.Import{ImportSpec: extraImport.Imports[1]})
+		builder.FWrite("if %s == nil {", param.Name())
+		builder.FTabWrite(2, "%s = fmt.Errorf(\"%%s: %%s is nil\", %q, %q)", results.NameAt(ast.ResultPrefix, len(results)-1), f.Name(), param.Name())
+		builder.FTabWrite(2, "return")
+		builder.FWrite("}")
+	}
+}
+
+// parseTimeout returns the duration expression of the `timeout` attribute of the statement, such as 3s.
+// A number without unit is the milliseconds enforced by the TimeoutMiddleware of juice, so it is ignored.
+func parseTimeout(statement juice.Statement) (string, error) {
+	timeout := statement.Attribute("timeout")
+	if timeout == "" {
+		return "", nil
+	}
+	if _, err := strconv.ParseInt(timeout, 10, 64); err == nil {
+		return "", nil
+	}
+	duration, err := time.ParseDuration(timeout)
+	if err != nil || duration <= 0 {
+		return "", fmt.Errorf("`%s` `timeout` must be a positive duration such as 3s, but got %s", statement.ID(), timeout)
+	}
+	units := []struct {
+		duration time.Duration
+		name     string
+	}{
+		{time.Hour, "Hour"},
+		{time.Minute, "Minute"},
+		{time.Second, "Second"},
+		{time.Millisecond, "Millisecond"},
+		{time.Microsecond, "Microsecond"},
+	}
+	for _, unit := range units {
+		if duration%unit.duration == 0 {
+			return fmt.Sprintf("%d*time.%s", duration/unit.duration, unit.name), nil
+		}
+	}
+	return fmt.Sprintf("time.Duration(%d)", duration), nil
+}
+
+// timeoutRowsTypeName returns the name of the type which wraps the rows returned by the implementation
+// to cancel the timeout when closed, e.g. UserRepositoryImpl => userRepositoryImplRows.
+func timeoutRowsTypeName(impl string) string {
+	r, size := utf8.DecodeRuneInString(impl)
+	return string(unicode.ToLower(r)) + impl[size:] + "Rows"
+}
+
 func (f *Function) Params() ast.ValueGroup {
 	return f.method.Params()
 }
@@ -86,6 +411,16 @@ func (f *Function) Name() string {
 	return f.method.Name()
 }
 
+// importPath returns the import path of the package imported with the name by the function.
+func (f *Function) importPath(name string) string {
+	for _, imp := range f.method.Imports(f.iface.File.Imports) {
+		if imp.Usage() == name {
+			return imp.UnQuote()
+		}
+	}
+	return ""
+}
+
 type FunctionGroup []*Function
 
 func (f FunctionGroup) String() string {
@@ -104,24 +439,550 @@ type FunctionBodyMakerProvider func(statement juice.Statement, function *Functio
 type readFuncBodyMaker struct {
 	statement juice.Statement
 	function  *Function
+	// keyField is the field of the map value which the key column is mapped to.
+	keyField string
+	// cfg is the configuration which declares the statement.
+	cfg juice.Configuration
+	// countStatement is the full name of the statement which counts the total of a page.
+	countStatement string
+	// notFound is the error returned instead of sql.ErrNoRows by the `notFound` attribute,
+	// qualified if the implementation is generated into another package.
+	notFound string
+}
+
+// resultKind is the kind of the first result of a read function, which decides how the rows are bound.
+type resultKind int
+
+const (
+	// resultDefault is bound by juice, e.g. T, *T, []T and []*T.
+	resultDefault resultKind = iota
+	// resultIter is iter.Seq2[T, error], see buildIter.
+	resultIter
+	// resultKeyedMap is map[K]V, keyed by the column named by the key attribute of the statement.
+	resultKeyedMap
+	// resultRowMap is map[string]any, which holds the columns of a single row.
+	resultRowMap
+	// resultRawRows is *sql.Rows or sql.Rows of juice, which are closed by the caller.
+	resultRawRows
+	// resultPage is ([]T, int64, error), the items of a page and the total counted by the countRef statement.
+	resultPage
+	// resultFound is (T, bool, error), a single row and whether it is found.
+	resultFound
+)
+
+// notFoundNil is the value of the `notFound` attribute which returns a nil pointer without error if no row is found.
+const notFoundNil = "nil"
+
+func (f *readFuncBodyMaker) resultKind() resultKind {
+	results := f.function.Results()
+	if len(results) == 0 {
+		return resultDefault
+	}
+	if _, ok := f.iterResult(); ok {
+		return resultIter
+	}
+	if len(results) == 3 {
+		if isBasic(results[1], types.Bool) {
+			return resultFound
+		}
+		return resultPage
+	}
+	switch first := results[0]; {
+	case isMap(first):
+		if f.statement.Attribute("key") != "" {
+			return resultKeyedMap
+		}
+		if isRowMap(first) {
+			return resultRowMap
+		}
+	case f.function.isRawRows(first):
+		return resultRawRows
+	}
+	return resultDefault
 }
 
 func (f *readFuncBodyMaker) check() error {
-	if len(f.function.method.Results()) != 2 {
-		return fmt.Errorf("%s: must have two results", f.function.method.Name())
+	kind := f.resultKind()
+	switch kind {
+	case resultIter:
+		if err := f.checkIter(); err != nil {
+			return err
+		}
+	case resultPage:
+		if err := f.checkPage(); err != nil {
+			return err
+		}
+	case resultFound:
+		if f.isList() {
+			return fmt.Errorf("%s: first result must be a single row if the second result is bool", f.function.Name())
+		}
+		if !isError(f.function.Results()[2]) {
+			return fmt.Errorf("%s: third result must be error", f.function.Name())
+		}
+	default:
+		if len(f.function.method.Results()) != 2 {
+			return fmt.Errorf("%s: must have two results", f.function.method.Name())
+		}
+		if !isError(f.function.Results()[1]) {
+			return fmt.Errorf("%s: second result must be error", f.function.method.Names)
+		}
+	}
+	if key := f.statement.Attribute("key"); key != "" && kind != resultKeyedMap {
+		return fmt.Errorf("`%s` `key` requires %s to return (map[K]T, error) indexed by the key column", f.statement.ID(), f.function.Name())
+	}
+	if isMap(f.function.Results()[0]) && kind == resultDefault {
+		return fmt.Errorf("%s: map result requires the key attribute of `%s` to name the key column, or must be map[string]any", f.function.Name(), f.statement.ID())
 	}
-	if f.function.Results()[1].TypeName() != "error" {
-		return fmt.Errorf("%s: second result must be error", f.function.method.Names)
+	if kind == resultKeyedMap {
+		column := f.statement.Attribute("key")
+		field, err := f.findKeyColumn(column)
+		if err != nil {
+			return fmt.Errorf("%s: key column %s: %w", f.function.Name(), column, err)
+		}
+		f.keyField = field
+	}
+	if err := f.checkNotFound(kind); err != nil {
+		return err
+	}
+	if err := f.function.parseAllowed(f.statement); err != nil {
+		return err
 	}
+	if err := f.function.parseCollections(); err != nil {
+		return err
+	}
+	timeout, err := parseTimeout(f.statement)
+	if err != nil {
+		return err
+	}
+	if timeout != "" && kind == resultRawRows && isPointer(f.function.Results()[0]) {
+		return fmt.Errorf("`%s` `timeout` is not supported by the *sql.Rows result of %s, which can not cancel it when closed, return sql.Rows of juice instead", f.statement.ID(), f.function.Name())
+	}
+	f.function.timeout = timeout
 	if len(f.function.Params()) == 0 {
 		return fmt.Errorf("%s: must have at least one argument", f.function.Name())
 	}
-	if f.function.Params()[0].TypeName() != "context.Context" {
+	if !f.function.isContext(f.function.Params()[0]) {
 		return fmt.Errorf("%s: first argument must be context.Context", f.function.Name())
 	}
 	return nil
 }
 
+// findKeyColumn returns the selector of the field of the map values which the key column is mapped to, e.g. ID.
+// The interface may be qualified when the implementation is generated into another package,
+// so the field is found by the resolved type of the map values, or by the imports of the interface otherwise.
+func (f *readFuncBodyMaker) findKeyColumn(column string) (string, error) {
+	result := f.function.Results()[0]
+	if t := result.Resolved(); t != nil {
+		elem := t.Underlying().(*types.Map).Elem()
+		if pointer, ok := elem.Underlying().(*types.Pointer); ok {
+			elem = pointer.Elem()
+		}
+		path, ok := findTaggedPath(elem, sqllib.ColumnTagName(), column)
+		if !ok {
+			return "", fmt.Errorf("no field of %s is mapped to column %s", types.TypeString(elem, f.function.qualifier), column)
+		}
+		field, key := path[len(path)-1], t.Underlying().(*types.Map).Key()
+		if !types.AssignableTo(field.Type(), key) {
+			return "", fmt.Errorf("field %s of %s can not be the key of %s", field.Name(), field.Type(), key)
+		}
+		names := make([]string, len(path))
+		for i, field := range path {
+			names[i] = field.Name()
+		}
+		return strings.Join(names, "."), nil
+	}
+	imports := f.function.iface.File.Imports
+	if imp := f.function.iface.PackageImport(); imp != nil {
+		imports = append(slices.Clone(imports), imp.ImportSpec)
+	}
+	mapType := result.Type.(*stdast.MapType)
+	return findColumnField(mapType.Value, column, f.function.iface.Dir, imports)
+}
+
+// isList reports whether the first result is a list of rows or values, which is empty instead of sql.ErrNoRows.
+func (f *readFuncBodyMaker) isList() bool {
+	return isSlice(f.function.Results()[0])
+}
+
+// checkNotFound checks the `notFound` attribute, which is either nil,
+// or the name of an error variable declared in the package of the interface returned instead of sql.ErrNoRows.
+// Without the types of the package, only the spelling of the name is checked.
+func (f *readFuncBodyMaker) checkNotFound(kind resultKind) error {
+	notFound := f.statement.Attribute("notFound")
+	if notFound == "" {
+		return nil
+	}
+	if kind != resultDefault || f.isList() {
+		return fmt.Errorf("`%s` `notFound` requires %s to return (T, error) or (*T, error) of a single row", f.statement.ID(), f.function.Name())
+	}
+	if notFound == notFoundNil {
+		if !isPointer(f.function.Results()[0]) {
+			return fmt.Errorf("`%s` `notFound` is nil, but the first result of %s is not a pointer", f.statement.ID(), f.function.Name())
+		}
+		f.notFound = notFound
+		return nil
+	}
+	if !token.IsIdentifier(notFound) {
+		return fmt.Errorf("`%s` `notFound` must be nil or the name of an error declared in the package, but got %s", f.statement.ID(), notFound)
+	}
+	iface := f.function.iface
+	if iface.Types != nil {
+		variable, ok := iface.Types.Scope().Lookup(notFound).(*types.Var)
+		if !ok || !types.AssignableTo(variable.Type(), types.Universe.Lookup("error").Type()) {
+			return fmt.Errorf("`%s` `notFound` must be nil or the name of an error variable declared in package %s, but got %s", f.statement.ID(), iface.Types.Name(), notFound)
+		}
+	}
+	f.notFound = notFound
+	if qualifier := iface.Qualifier(); qualifier != "" {
+		if !token.IsExported(notFound) {
+			return fmt.Errorf("`%s` `notFound` %s must be exported to be returned from another package", f.statement.ID(), notFound)
+		}
+		f.notFound = qualifier + "." + notFound
+		f.function.imports = append(f.function.imports, iface.PackageImport())
+	}
+	return nil
+}
+
+// minIterGoVersion is the minimum go version which supports range over iter.Seq2.
+const minIterGoVersion = "1.23"
+
+func (f *readFuncBodyMaker) checkIter() error {
+	goVersion, err := module.GoVersion(f.function.iface.Dir)
+	if err != nil {
+		return err
+	}
+	if version.Compare("go"+goVersion, "go"+minIterGoVersion) < 0 {
+		return fmt.Errorf("%s: iter.Seq2 requires go %s or newer in go.mod, but got %q", f.function.Name(), minIterGoVersion, goVersion)
+	}
+	results := f.function.Results()
+	switch len(results) {
+	case 1:
+		return nil
+	case 2:
+		if !isError(results[1]) {
+			return fmt.Errorf("%s: second result must be error", f.function.Name())
+		}
+		return nil
+	default:
+		return fmt.Errorf("%s: must have at most two results", f.function.Name())
+	}
+}
+
+func (f *readFuncBodyMaker) checkPage() error {
+	results := f.function.Results()
+	if !isSlice(results[0]) {
+		return fmt.Errorf("%s: first result must be a slice of the items", f.function.Name())
+	}
+	if !isBasic(results[1], types.Int64) && !isBasic(results[1], types.Int) {
+		return fmt.Errorf("%s: second result must be int64 or int of the total", f.function.Name())
+	}
+	if !isError(results[2]) {
+		return fmt.Errorf("%s: third result must be error", f.function.Name())
+	}
+	countRef := f.statement.Attribute("countRef")
+	if countRef == "" {
+		return fmt.Errorf("`%s` `countRef` is required to count the total of %s", f.statement.ID(), f.function.Name())
+	}
+	// countRef is the id of a statement in the same mapper, or the full name of a statement
+	namespace := strings.TrimSuffix(f.statement.Name(), "."+f.statement.ID())
+	count, err := f.cfg.GetStatement(namespace + "." + countRef)
+	if err != nil {
+		if count, err = f.cfg.GetStatement(countRef); err != nil {
+			return fmt.Errorf("`%s` `countRef` statement %s not found", f.statement.ID(), countRef)
+		}
+	}
+	if !count.Action().ForRead() {
+		return fmt.Errorf("`%s` `countRef` statement %s must be a select statement", f.statement.ID(), countRef)
+	}
+	f.countStatement = count.Name()
+	return nil
+}
+
+// iterResult returns the element type of the first result if it is iter.Seq2[T, error].
+func (f *readFuncBodyMaker) iterResult() (string, bool) {
+	results := f.function.Results()
+	if len(results) == 0 {
+		return "", false
+	}
+	return f.function.seq2Elem(results[0])
+}
+
+// writeGuards writes the checks of the params, which return before executing the query.
+// The empty collections return the empty result, except for the single row results which are not found then.
+func (f *readFuncBodyMaker) writeGuards(builder *funcBodyWriter) {
+	f.function.writeNilChecks(builder)
+	switch kind := f.resultKind(); kind {
+	case resultIter:
+		elem, _ := f.iterResult()
+		ret := fmt.Sprintf("return func(func(%s, error) bool) {}", elem)
+		if len(f.function.Results()) == 2 {
+			ret += ", nil"
+		}
+		f.function.writeEmptyCollections(builder, ret)
+	case resultKeyedMap, resultPage, resultFound:
+		f.function.writeEmptyCollections(builder, "return")
+	case resultDefault:
+		if f.isList() {
+			f.function.writeEmptyCollections(builder, "return")
+		}
+	}
+}
+
+// writeTimeout writes the timeout of the query which is canceled when the function returns.
+// The rows of iter.Seq2 and raw rows results outlive the function, whose timeout is written by their builders.
+func (f *readFuncBodyMaker) writeTimeout(builder *funcBodyWriter) {
+	if f.function.timeout == "" {
+		return
+	}
+	if kind := f.resultKind(); kind == resultIter || kind == resultRawRows {
+		return
+	}
+	f.function.beginTimeout(builder, 1)
+	builder.FWrite("defer cancel()")
+}
+
+// buildResult writes the body for the results which are not bound by juice directly.
+// It reports whether the body is written.
+func (f *readFuncBodyMaker) buildResult(builder *funcBodyWriter) bool {
+	switch f.resultKind() {
+	case resultIter:
+		elem, _ := f.iterResult()
+		f.buildIter(builder, elem)
+	case resultKeyedMap:
+		f.buildKeyedMap(builder)
+	case resultRowMap:
+		f.buildRowMap(builder)
+	case resultRawRows:
+		f.buildRawRows(builder)
+	case resultPage:
+		f.buildPage(builder)
+	case resultFound:
+		f.buildSingleRow(builder)
+	default:
+		if f.statement.Attribute("notFound") == "" {
+			return false
+		}
+		f.buildSingleRow(builder)
+	}
+	return true
+}
+
+// query returns the expression which executes the query of the statement and returns the rows.
+func (f *readFuncBodyMaker) query() string {
+	ctx := f.function.Params().NameAt(ast.ParamPrefix, 0)
+	return fmt.Sprintf("juice.ManagerFromContext(%s).Object(%s).QueryContext(%s, %s)", ctx, f.function.statement(), ctx, formatParams(f.function.Params()))
+}
+
+// buildKeyedMap writes the body which queries the list and indexes it by the key field.
+func (f *readFuncBodyMaker) buildKeyedMap(builder *funcBodyWriter) {
+	elem, _ := f.function.mapElem(f.function.Results()[0])
+	query := "QueryListContext"
+	if strings.HasPrefix(elem, "*") {
+		query = "QueryList2Context"
+	}
+	builder.FWrite(
+		"items, err := juice.%s[%s](%s, %s, %s)",
+		query,
+		strings.TrimPrefix(elem, "*"),
+		f.function.Params().NameAt(ast.ParamPrefix, 0),
+		f.function.statement(),
+		formatParams(f.function.Params()),
+	)
+	builder.FWrite("if err != nil {")
+	builder.FTabWrite(2, "return nil, err")
+	builder.FWrite("}")
+	builder.FWrite("ret := make(%s, len(items))", f.function.Results()[0].TypeName())
+	builder.FWrite("for _, item := range items {")
+	builder.FTabWrite(2, "ret[item.%s] = item", f.keyField)
+	builder.FWrite("}")
+	builder.FWrite("return ret, nil")
+}
+
+// buildPage writes the body which queries the items of the page and counts the total with the same params.
+// The results are assigned to the named results, which may be named like the local variables otherwise declared.
+func (f *readFuncBodyMaker) buildPage(builder *funcBodyWriter) {
+	results := f.function.Results()
+	elem, _ := f.function.listElem(results[0])
+	query := "QueryListContext"
+	if strings.HasPrefix(elem, "*") {
+		query = "QueryList2Context"
+	}
+	ctx := f.function.Params().NameAt(ast.ParamPrefix, 0)
+	params := formatParams(f.function.Params())
+	items, total, err := results.NameAt(ast.ResultPrefix, 0), results.NameAt(ast.ResultPrefix, 1), results.NameAt(ast.ResultPrefix, 2)
+	builder.FWrite("%s, %s = juice.%s[%s](%s, %s, %s)", items, err, query, strings.TrimPrefix(elem, "*"), ctx, f.function.statement(), params)
+	builder.FWrite("if %s != nil {", err)
+	builder.FTabWrite(2, "return nil, 0, %s", err)
+	builder.FWrite("}")
+	builder.FWrite("%s, %s = juice.QueryContext[%s](%s, %q, %s)", total, err, results[1].TypeName(), ctx, f.countStatement, params)
+	builder.FWrite("if %s != nil {", err)
+	builder.FTabWrite(2, "return nil, 0, %s", err)
+	builder.FWrite("}")
+	builder.FWrite("return %s, %s, nil", items, total)
+}
+
+// buildSingleRow writes the body which queries a single row and handles sql.ErrNoRows,
+// by the `notFound` attribute, or by the bool result which reports whether the row is found.
+// The first result is returned as the zero value when no row is found.
+func (f *readFuncBodyMaker) buildSingleRow(builder *funcBodyWriter) {
+	f.function.imports = append(f.function.imports,
+		&ast.Import{ImportSpec: extraImport.Imports[3]},
+		&ast.Import{ImportSpec: extraImport.Imports[4]},
+	)
+	results := f.function.Results()
+	result := results[0]
+	zero := results.NameAt(ast.ResultPrefix, 0)
+	ret := "ret"
+	if result.IsPointerType() {
+		zero, ret = "nil", "&ret"
+	}
+	builder.FWrite(
+		"ret, err := juice.QueryContext[%s](%s, %s, %s)",
+		result.DirectTypename(),
+		f.function.Params().NameAt(ast.ParamPrefix, 0),
+		f.function.statement(),
+		formatParams(f.function.Params()),
+	)
+	builder.FWrite("if errors.Is(err, sql.ErrNoRows) {")
+	switch {
+	case len(results) == 3:
+		builder.FTabWrite(2, "return %s, false, nil", zero)
+	case f.notFound == notFoundNil:
+		builder.FTabWrite(2, "return nil, nil")
+	default:
+		builder.FTabWrite(2, "return %s, %s", zero, f.notFound)
+	}
+	builder.FWrite("}")
+	builder.FWrite("if err != nil {")
+	if len(results) == 3 {
+		builder.FTabWrite(2, "return %s, false, err", zero)
+		builder.FWrite("}")
+		builder.FWrite("return %s, true, nil", ret)
+		return
+	}
+	builder.FTabWrite(2, "return %s, err", zero)
+	builder.FWrite("}")
+	builder.FWrite("return %s, nil", ret)
+}
+
+// buildRowMap writes the body which scans the columns of the single row into a map.
+func (f *readFuncBodyMaker) buildRowMap(builder *funcBodyWriter) {
+	f.function.imports = append(f.function.imports, &ast.Import{ImportSpec: extraImport.Imports[3]})
+	builder.FWrite("rows, err := %s", f.query())
+	builder.FWrite("if err != nil {")
+	builder.FTabWrite(2, "return nil, err")
+	builder.FWrite("}")
+	builder.FWrite("defer func() { _ = rows.Close() }()")
+	builder.FWrite("if !rows.Next() {")
+	builder.FTabWrite(2, "if err = rows.Err(); err != nil {")
+	builder.FTabWrite(2, "\treturn nil, err")
+	builder.FTabWrite(2, "}")
+	builder.FTabWrite(2, "return nil, sql.ErrNoRows")
+	builder.FWrite("}")
+	builder.FWrite("columns, err := rows.Columns()")
+	builder.FWrite("if err != nil {")
+	builder.FTabWrite(2, "return nil, err")
+	builder.FWrite("}")
+	builder.FWrite("values := make([]any, len(columns))")
+	builder.FWrite("dest := make([]any, len(columns))")
+	builder.FWrite("for index := range values {")
+	builder.FTabWrite(2, "dest[index] = &values[index]")
+	builder.FWrite("}")
+	builder.FWrite("if err = rows.Scan(dest...); err != nil {")
+	builder.FTabWrite(2, "return nil, err")
+	builder.FWrite("}")
+	builder.FWrite("ret := make(%s, len(columns))", f.function.Results()[0].TypeName())
+	builder.FWrite("for index, column := range columns {")
+	builder.FTabWrite(2, "ret[column] = values[index]")
+	builder.FWrite("}")
+	builder.FWrite("return ret, nil")
+}
+
+// buildRawRows writes the body which returns the rows to the caller, who must close them.
+func (f *readFuncBodyMaker) buildRawRows(builder *funcBodyWriter) {
+	retType := f.function.Results()[0].TypeName()
+	if !strings.HasPrefix(retType, "*") {
+		// sql.Rows of juice
+		if f.function.timeout == "" {
+			builder.FWrite("return %s", f.query())
+			return
+		}
+		f.function.timeoutRows = retType
+		f.function.beginTimeout(builder, 1)
+		builder.FWrite("rows, err := %s", f.query())
+		builder.FWrite("if err != nil {")
+		builder.FTabWrite(2, "cancel()")
+		builder.FTabWrite(2, "return nil, err")
+		builder.FWrite("}")
+		builder.FWrite("return &%s{Rows: rows, cancel: cancel}, nil", timeoutRowsTypeName(strings.SplitN(f.function.receiver, "[", 2)[0]))
+		return
+	}
+	f.function.imports = append(f.function.imports, &ast.Import{ImportSpec: extraImport.Imports[1]})
+	builder.FWrite("rows, err := %s", f.query())
+	builder.FWrite("if err != nil {")
+	builder.FTabWrite(2, "return nil, err")
+	builder.FWrite("}")
+	builder.FWrite("ret, ok := rows.(%s)", retType)
+	builder.FWrite("if !ok {")
+	builder.FTabWrite(2, "_ = rows.Close()")
+	builder.FTabWrite(2, "return nil, fmt.Errorf(\"unexpected rows type %%T\", rows)")
+	builder.FWrite("}")
+	builder.FWrite("return ret, nil")
+}
+
+// buildIter writes the body which iterates over the rows lazily.
+// The rows are closed when the iteration completes or the consumer stops early.
+// If the function returns the iterator only, the query is executed when the iteration starts,
+// and its error is yielded. Otherwise the query is executed at once and its error is returned.
+func (f *readFuncBodyMaker) buildIter(builder *funcBodyWriter, elem string) {
+	query := f.query()
+	timeout := f.function.timeout != ""
+	if len(f.function.Results()) == 1 {
+		builder.FWrite("return func(yield func(%s, error) bool) {", elem)
+		if timeout {
+			f.function.beginTimeout(builder, 2)
+			builder.FTabWrite(2, "defer cancel()")
+		}
+		builder.FTabWrite(2, "var zero %s", elem)
+		builder.FTabWrite(2, "rows, err := %s", query)
+		builder.FTabWrite(2, "if err != nil {")
+		builder.FTabWrite(3, "yield(zero, err)")
+		builder.FTabWrite(3, "return")
+		builder.FTabWrite(2, "}")
+		builder.FTabWrite(2, "defer func() { _ = rows.Close() }()")
+		builder.FTabWrite(2, "seq, err := juice.Iter[%s](rows)", elem)
+		builder.FTabWrite(2, "if err != nil {")
+		builder.FTabWrite(3, "yield(zero, err)")
+		builder.FTabWrite(3, "return")
+		builder.FTabWrite(2, "}")
+		builder.FTabWrite(2, "seq(yield)")
+		builder.FWrite("}")
+		return
+	}
+	// the timeout is canceled when the rows are closed
+	closeRows := "_ = rows.Close()"
+	if timeout {
+		f.function.beginTimeout(builder, 1)
+		closeRows = "_ = rows.Close(); cancel()"
+	}
+	builder.FWrite("rows, err := %s", query)
+	builder.FWrite("if err != nil {")
+	if timeout {
+		builder.FTabWrite(2, "cancel()")
+	}
+	builder.FTabWrite(2, "return nil, err")
+	builder.FWrite("}")
+	builder.FWrite("seq, err := juice.Iter[%s](rows)", elem)
+	builder.FWrite("if err != nil {")
+	builder.FTabWrite(2, "%s", closeRows)
+	builder.FTabWrite(2, "return nil, err")
+	builder.FWrite("}")
+	builder.FWrite("return func(yield func(%s, error) bool) {", elem)
+	builder.FTabWrite(2, "defer func() { %s }()", closeRows)
+	builder.FTabWrite(2, "seq(yield)")
+	builder.FWrite("}, nil")
+}
+
 type readFuncBodyMakerV1 struct {
 	*readFuncBodyMaker
 }
@@ -137,19 +998,27 @@ func (f *readFuncBodyMakerV1) Make() error {
 func (f *readFuncBodyMakerV1) build() {
 	var builder funcBodyWriter
 
-	iface := fmt.Sprintf("%s(%s)", f.function.typename, f.function.receiverAlias())
+	f.function.writeAllowed(&builder)
+	f.writeGuards(&builder)
+	f.writeTimeout(&builder)
+
+	if f.buildResult(&builder) {
+		f.function.body = formatCode(builder.String())
+		return
+	}
 
 	retType := f.function.Results()[0].TypeName()
 	query := formatParams(f.function.Params())
 
-	isArrayType := strings.HasPrefix(retType, "[]")
+	// []byte is a scalar value of a single column
+	elem, isArrayType := f.function.listElem(f.function.Results()[0])
 
 	_, err := f.statement.ResultMap()
 
 	// if isArrayType is true and the error is ErrResultMapNotSet
 	if isArrayType && errors.Is(err, sqllib.ErrResultMapNotSet) {
 		// if is an array type
-		retType = retType[2:]
+		retType = elem
 		isPointer := strings.HasPrefix(retType, "*")
 		if isPointer {
 			retType = retType[1:]
@@ -159,7 +1028,7 @@ func (f *readFuncBodyMakerV1) build() {
 				"return juice.QueryList2Context[%s](%s, %s, %s)",
 				retType,
 				f.function.Params().NameAt(ast.ParamPrefix, 0),
-				fmt.Sprintf("%s.%s", iface, f.function.Name()),
+				f.function.statement(),
 				query,
 			)
 		} else {
@@ -167,7 +1036,7 @@ func (f *readFuncBodyMakerV1) build() {
 				"return juice.QueryListContext[%s](%s, %s, %s)",
 				retType,
 				f.function.Params().NameAt(ast.ParamPrefix, 0),
-				fmt.Sprintf("%s.%s", iface, f.function.Name()),
+				f.function.statement(),
 				query,
 			)
 		}
@@ -185,11 +1054,11 @@ func (f *readFuncBodyMakerV1) build() {
 				"ret, err := juice.QueryContext[%s](%s, %s, %s)",
 				retType,
 				f.function.Params().NameAt(ast.ParamPrefix, 0),
-				fmt.Sprintf("%s.%s", iface, f.function.Name()),
+				f.function.statement(),
 				query,
 			)
 			builder.FWrite("if err != nil {")
-			builder.FWrite("\treturn nil, err")
+			builder.FTabWrite(2, "return nil, err")
 			builder.FWrite("}")
 			builder.FWrite("return &ret, nil")
 		} else {
@@ -197,7 +1066,7 @@ func (f *readFuncBodyMakerV1) build() {
 				"return juice.QueryContext[%s](%s, %s, %s)",
 				retType,
 				f.function.Params().NameAt(ast.ParamPrefix, 0),
-				fmt.Sprintf("%s.%s", iface, f.function.Name()),
+				f.function.statement(),
 				query,
 			)
 		}
@@ -221,12 +1090,14 @@ func (f *readFuncBodyMakerV2) Make() error {
 func (f *readFuncBodyMakerV2) build() {
 	var builder funcBodyWriter
 
-	iface := fmt.Sprintf("%s(%s)", f.function.typename, f.function.receiverAlias())
+	f.function.writeAllowed(&builder)
+	f.writeGuards(&builder)
 
 	retType := f.function.Results()[0].TypeName()
 	query := formatParams(f.function.Params())
 
-	isArrayType := strings.HasPrefix(retType, "[]")
+	// []byte is a scalar value of a single column
+	elem, isArrayType := f.function.listElem(f.function.Results()[0])
 
 	_, err := f.statement.ResultMap()
 
@@ -237,10 +1108,17 @@ func (f *readFuncBodyMakerV2) build() {
 		f.function.receiverAlias(),
 	)
 
+	f.writeTimeout(&builder)
+
+	if f.buildResult(&builder) {
+		f.function.body = formatCode(builder.String())
+		return
+	}
+
 	// if isArrayType is true and the error is ErrResultMapNotSet
 	if isArrayType && errors.Is(err, sqllib.ErrResultMapNotSet) {
 		// if is an array type
-		retType = retType[2:]
+		retType = elem
 		isPointer := strings.HasPrefix(retType, "*")
 		if isPointer {
 			retType = retType[1:]
@@ -250,7 +1128,7 @@ func (f *readFuncBodyMakerV2) build() {
 				"return juice.QueryList2Context[%s](%s, %s, %s)",
 				retType,
 				f.function.Params().NameAt(ast.ParamPrefix, 0),
-				fmt.Sprintf("%s.%s", iface, f.function.Name()),
+				f.function.statement(),
 				query,
 			)
 		} else {
@@ -258,7 +1136,7 @@ func (f *readFuncBodyMakerV2) build() {
 				"return juice.QueryListContext[%s](%s, %s, %s)",
 				retType,
 				f.function.Params().NameAt(ast.ParamPrefix, 0),
-				fmt.Sprintf("%s.%s", iface, f.function.Name()),
+				f.function.statement(),
 				query,
 			)
 		}
@@ -276,11 +1154,11 @@ func (f *readFuncBodyMakerV2) build() {
 				"ret, err := juice.QueryContext[%s](%s, %s, %s)",
 				retType,
 				f.function.Params().NameAt(ast.ParamPrefix, 0),
-				fmt.Sprintf("%s.%s", iface, f.function.Name()),
+				f.function.statement(),
 				query,
 			)
 			builder.FWrite("if err != nil {")
-			builder.FWrite("\treturn nil, err")
+			builder.FTabWrite(2, "return nil, err")
 			builder.FWrite("}")
 			builder.FWrite("return &ret, nil")
 		} else {
@@ -288,7 +1166,7 @@ func (f *readFuncBodyMakerV2) build() {
 				"return juice.QueryContext[%s](%s, %s, %s)",
 				retType,
 				f.function.Params().NameAt(ast.ParamPrefix, 0),
-				fmt.Sprintf("%s.%s", iface, f.function.Name()),
+				f.function.statement(),
 				query,
 			)
 		}
@@ -303,9 +1181,30 @@ type GenericFunctionBodyMaker struct {
 	writeFuncBodyMakerProvider FunctionBodyMakerProvider
 }
 
+// isQuery reports whether the insert, update or delete statement returns rows, e.g. with a RETURNING clause.
+// It is either marked by the `query` attribute, or detected by the results of the function,
+// which are not any of the results of a write statement.
+func (f *GenericFunctionBodyMaker) isQuery() bool {
+	if query := f.statement.Attribute("query"); query != "" {
+		return query == "true"
+	}
+	results := f.function.Results()
+	switch len(results) {
+	case 1:
+		return !isError(results[0])
+	case 2:
+		if f.function.isSQLResult(results[0]) || isBasic(results[0], types.Int64) || isBasic(results[0], types.Bool) {
+			return false
+		}
+		return isError(results[1])
+	default:
+		return false
+	}
+}
+
 func (f *GenericFunctionBodyMaker) Make() error {
 	var bodyMaker FunctionBodyMaker
-	if f.statement.Action().ForRead() {
+	if f.statement.Action().ForRead() || f.isQuery() {
 		bodyMaker = f.readFuncBodyMakerProvider(f.statement, f.function)
 	} else {
 		bodyMaker = f.writeFuncBodyMakerProvider(f.statement, f.function)
@@ -326,35 +1225,16 @@ func (f writeFuncBodyMaker) check() error {
 	case 0:
 		return fmt.Errorf("%s: must have at least one argument", f.function.Name())
 	case 1:
-		if params[0].TypeName() != "context.Context" {
+		if !f.function.isContext(params[0]) {
 			return fmt.Errorf("%s: first argument must be context.Context", f.function.Name())
 		}
 	case 2:
-		if params[0].TypeName() != "context.Context" {
+		if !f.function.isContext(params[0]) {
 			return fmt.Errorf("%s: first argument must be context.Context", f.function.Name())
 		}
-		// if `useGeneratedKeys` is true, the second parameter must be a pointer or a pointer array type
-		useGeneratedKeys := f.statement.Attribute("useGeneratedKeys")
-		if useGeneratedKeys == "true" {
-			// if the second parameter is not a pointer
-			param1 := params[1]
-			if arrayType, ok := param1.Field.Type.(*stdast.ArrayType); ok {
-				// if arrayType.Elt is not a pointer
-				starType, ok := arrayType.Elt.(*stdast.StarExpr)
-				if !ok {
-					return fmt.Errorf("`%s` `useGeneratedKeys` is true, but `%s` is not a pointer array type", f.statement.ID(), param1.Name())
-				}
-				// todo check the starType.X is a struct type
-				_ = starType
-			} else {
-				// not an array type
-				// ensure it is a pointer struct type
-				starType, ok := param1.Field.Type.(*stdast.StarExpr)
-				if !ok {
-					return fmt.Errorf("`%s` `useGeneratedKeys` is true, but `%s` is not a pointer type", f.statement.ID(), param1.Name())
-				}
-				// todo check the starType.X is a struct type
-				_ = starType
+		if f.statement.Attribute("useGeneratedKeys") == "true" {
+			if err := f.checkGeneratedKeys(params[1]); err != nil {
+				return err
 			}
 		}
 	default:
@@ -366,6 +1246,30 @@ func (f writeFuncBodyMaker) check() error {
 		}
 	}
 
+	if err := f.checkBatchSize(); err != nil {
+		return err
+	}
+	if err := f.function.parseAllowed(f.statement); err != nil {
+		return err
+	}
+	if err := f.function.parseCollections(); err != nil {
+		return err
+	}
+	timeout, err := parseTimeout(f.statement)
+	if err != nil {
+		return err
+	}
+	f.function.timeout = timeout
+
+	if expectRows := f.statement.Attribute("expectRows"); expectRows != "" {
+		if action := f.statement.Action(); action != sqllib.Update && action != sqllib.Delete {
+			return fmt.Errorf("`%s` `expectRows` is only supported by update and delete statements", f.statement.ID())
+		}
+		if _, err := strconv.ParseUint(expectRows, 10, 64); err != nil && expectRows != expectRowsAny {
+			return fmt.Errorf("`%s` `expectRows` must be a number or %s, but got %s", f.statement.ID(), expectRowsAny, expectRows)
+		}
+	}
+
 	// check results
 
 	results := f.function.Results()
@@ -374,14 +1278,30 @@ func (f writeFuncBodyMaker) check() error {
 	case 0:
 		return fmt.Errorf("%s: must have one result", f.function.Name())
 	case 1:
-		if results[0].TypeName() != "error" {
+		if !isError(results[0]) {
 			return fmt.Errorf("%s: result must be error", f.function.Name())
 		}
 	case 2:
-		if results[0].TypeName() != "sql.Result" {
-			return fmt.Errorf("%s: first result must be sql.Result", f.function.Name())
+		switch {
+		case f.function.isSQLResult(results[0]):
+		case isBasic(results[0], types.Int64):
+			if err := f.checkReturning(); err != nil {
+				return err
+			}
+			if returns := f.returns(); returns != returnsRowsAffected && returns != returnsLastInsertId {
+				return fmt.Errorf("`%s` `returns` must be %s or %s, but got %s", f.statement.ID(), returnsRowsAffected, returnsLastInsertId, returns)
+			}
+		case isBasic(results[0], types.Bool):
+			if err := f.checkReturning(); err != nil {
+				return err
+			}
+			if returns := f.returns(); returns != returnsRowsAffected {
+				return fmt.Errorf("`%s` `returns` must be %s for bool result, but got %s", f.statement.ID(), returnsRowsAffected, returns)
+			}
+		default:
+			return fmt.Errorf("%s: first result must be sql.Result, int64 or bool", f.function.Name())
 		}
-		if results[1].TypeName() != "error" {
+		if !isError(results[1]) {
 			return fmt.Errorf("%s: second result must be error", f.function.Name())
 		}
 	default:
@@ -390,6 +1310,208 @@ func (f writeFuncBodyMaker) check() error {
 	return nil
 }
 
+// returningClause matches the RETURNING clause of an insert, update or delete statement, which returns rows.
+var returningClause = regexp.MustCompile(`(?i)\breturning\b`)
+
+// checkReturning checks that the statement executed for an int64 or bool result does not return rows,
+// unless the `query` attribute says so. Such a result is the rows affected or the last inserted id,
+// so a RETURNING clause which is meant to return the row is silently ignored otherwise.
+func (f writeFuncBodyMaker) checkReturning() error {
+	if f.statement.Attribute("query") != "" || f.function.source == nil || !returningClause.MatchString(f.function.source.SQL) {
+		return nil
+	}
+	return fmt.Errorf("`%s` has a RETURNING clause, but %s is executed without querying the returned rows, set `query` to true to query them, or to false to execute it", f.statement.ID(), f.function.Name())
+}
+
+const (
+	// returnsRowsAffected makes an int64 result the number of rows affected, and a bool result whether any row is affected.
+	returnsRowsAffected = "rowsAffected"
+	// returnsLastInsertId makes an int64 result the last inserted id.
+	returnsLastInsertId = "lastInsertId"
+)
+
+// checkGeneratedKeys checks the param which the generated keys are set to with `useGeneratedKeys`,
+// which must be a pointer to a struct, or a slice of them for a batch insert.
+// The key field, named by `keyProperty` or tagged by autoincr:"true", must be an exported signed integer,
+// otherwise juice fails to set it at runtime. Without the types of the package, only the spelling of the param is checked.
+func (f writeFuncBodyMaker) checkGeneratedKeys(param *ast.Value) error {
+	t := param.Resolved()
+	if t == nil {
+		if arrayType, ok := param.Field.Type.(*stdast.ArrayType); ok {
+			if _, ok = arrayType.Elt.(*stdast.StarExpr); !ok {
+				return fmt.Errorf("`%s` `useGeneratedKeys` is true, but `%s` is not a pointer array type", f.statement.ID(), param.Name())
+			}
+		} else if _, ok = param.Field.Type.(*stdast.StarExpr); !ok {
+			return fmt.Errorf("`%s` `useGeneratedKeys` is true, but `%s` is not a pointer type", f.statement.ID(), param.Name())
+		}
+		return nil
+	}
+	elem, kind := t, "pointer"
+	if slice, ok := t.Underlying().(*types.Slice); ok {
+		elem, kind = slice.Elem(), "pointer array"
+	}
+	pointer, ok := elem.Underlying().(*types.Pointer)
+	if !ok {
+		return fmt.Errorf("`%s` `useGeneratedKeys` is true, but `%s` is not a %s type", f.statement.ID(), param.Name(), kind)
+	}
+	qualifier := func(pkg *types.Package) string {
+		if pkg == f.function.iface.Types {
+			return ""
+		}
+		return pkg.Name()
+	}
+	if _, ok = pointer.Elem().Underlying().(*types.Struct); !ok {
+		return fmt.Errorf("`%s` `useGeneratedKeys` is true, but `%s` is a %s type of %s, which is not a struct",
+			f.statement.ID(), param.Name(), kind, types.TypeString(pointer.Elem(), qualifier))
+	}
+	keyProperty := f.statement.Attribute("keyProperty")
+	field, err := findKeyField(pointer.Elem(), keyProperty, qualifier)
+	if err != nil {
+		if keyProperty == "" {
+			return fmt.Errorf("`%s` `useGeneratedKeys` is true, but %w, set `keyProperty` to the key field", f.statement.ID(), err)
+		}
+		return fmt.Errorf("`%s` `keyProperty` %s: %w", f.statement.ID(), keyProperty, err)
+	}
+	if !field.Exported() {
+		return fmt.Errorf("`%s` the key field %s is not exported", f.statement.ID(), field.Name())
+	}
+	basic, ok := field.Type().Underlying().(*types.Basic)
+	if !ok || basic.Info()&types.IsInteger == 0 || basic.Info()&types.IsUnsigned != 0 {
+		return fmt.Errorf("`%s` the key field %s is %s, not a signed integer",
+			f.statement.ID(), field.Name(), types.TypeString(field.Type(), qualifier))
+	}
+	return nil
+}
+
+// checkBatchSize checks the `batchSize` attribute of the statement.
+// juice executes the statement in chunks of the batch size when its parameter is a slice,
+// or a map whose only value is a slice, and adds up the results of the chunks,
+// so the slice must be the only parameter besides context.Context.
+func (f writeFuncBodyMaker) checkBatchSize() error {
+	batchSize := f.statement.Attribute("batchSize")
+	if batchSize == "" {
+		return nil
+	}
+	if f.statement.Action() != sqllib.Insert {
+		return fmt.Errorf("`%s` `batchSize` is only supported by insert statements", f.statement.ID())
+	}
+	if size, err := strconv.ParseInt(batchSize, 10, 64); err != nil || size <= 0 {
+		return fmt.Errorf("`%s` `batchSize` must be a positive number, but got %s", f.statement.ID(), batchSize)
+	}
+	params := f.function.Params()
+	// []byte is a single value
+	if len(params) == 2 && isSlice(params[1]) {
+		return nil
+	}
+	return fmt.Errorf("`%s` `batchSize` requires a slice as the only parameter besides context.Context", f.statement.ID())
+}
+
+// expectRowsAny is the value of the `expectRows` attribute which expects at least one row to be affected.
+const expectRowsAny = ">0"
+
+// returns returns the `returns` attribute of the statement, which defaults to rowsAffected.
+func (f writeFuncBodyMaker) returns() string {
+	if returns := f.statement.Attribute("returns"); returns != "" {
+		return returns
+	}
+	return returnsRowsAffected
+}
+
+// writeGuards writes the checks of the params, which return before executing the statement.
+// The empty collections return no rows affected, unless the rows affected are expected.
+func (f writeFuncBodyMaker) writeGuards(builder *funcBodyWriter) {
+	f.function.writeNilChecks(builder)
+	if len(f.function.collections) == 0 || f.statement.Attribute("expectRows") != "" {
+		return
+	}
+	if results := f.function.Results(); len(results) == 2 && f.function.isSQLResult(results[0]) {
+		f.function.imports = append(f.function.imports, &ast.Import{ImportSpec: extraImport.Imports[6]})
+		f.function.writeEmptyCollections(builder, "return driver.RowsAffected(0), nil")
+		return
+	}
+	f.function.writeEmptyCollections(builder, "return")
+}
+
+// buildExec writes the body which executes the statement and returns its result.
+func (f writeFuncBodyMaker) buildExec(builder *funcBodyWriter) {
+	exec := fmt.Sprintf(
+		"juice.ExecContext(%s, %s, %s)",
+		f.function.Params().NameAt(ast.ParamPrefix, 0),
+		f.function.statement(),
+		formatParams(f.function.Params()),
+	)
+	if f.function.timeout != "" {
+		f.function.beginTimeout(builder, 1)
+		builder.FWrite("defer cancel()")
+	}
+	results := f.function.Results()
+	expectRows := f.statement.Attribute("expectRows")
+	if expectRows == "" {
+		if len(results) == 1 {
+			builder.FWrite("_, err := %s", exec)
+			builder.FWrite("return err")
+			return
+		}
+		if f.function.isSQLResult(results[0]) {
+			builder.FWrite("return %s", exec)
+			return
+		}
+	}
+
+	// zero is the zero value of the first result followed by a comma, empty if error is the only result
+	var zero string
+	if len(results) == 2 {
+		switch {
+		case f.function.isSQLResult(results[0]):
+			zero = "nil, "
+		case isBasic(results[0], types.Int64):
+			zero = "0, "
+		case isBasic(results[0], types.Bool):
+			zero = "false, "
+		}
+	}
+	lastInsertId := len(results) == 2 && isBasic(results[0], types.Int64) && f.returns() == returnsLastInsertId
+
+	builder.FWrite("result, err := %s", exec)
+	builder.FWrite("if err != nil {")
+	builder.FTabWrite(2, "return %serr", zero)
+	builder.FWrite("}")
+	if expectRows == "" && lastInsertId {
+		builder.FWrite("return result.LastInsertId()")
+		return
+	}
+	builder.FWrite("affected, err := result.RowsAffected()")
+	builder.FWrite("if err != nil {")
+	builder.FTabWrite(2, "return %serr", zero)
+	builder.FWrite("}")
+	if expectRows != "" {
+		if expectRows == expectRowsAny {
+			builder.FWrite("if affected <= 0 {")
+		} else {
+			builder.FWrite("if affected != %s {", expectRows)
+		}
+		builder.FTabWrite(2,
+			"return %sfmt.Errorf(\"%%w: %%s expected %%s rows, got %%d\", %s, %q, %q, affected)",
+			zero, errUnexpectedRowCount, f.function.Name(), expectRows,
+		)
+		builder.FWrite("}")
+		f.function.checksRowCount = true
+		f.function.imports = append(f.function.imports, &ast.Import{ImportSpec: extraImport.Imports[1]})
+	}
+	switch {
+	case len(results) == 1:
+		builder.FWrite("return nil")
+	case f.function.isSQLResult(results[0]):
+		builder.FWrite("return result, nil")
+	case lastInsertId:
+		builder.FWrite("return result.LastInsertId()")
+	case isBasic(results[0], types.Bool):
+		builder.FWrite("return affected > 0, nil")
+	default:
+		builder.FWrite("return affected, nil")
+	}
+}
+
 type writeFuncBodyMakerV1 struct {
 	*writeFuncBodyMaker
 }
@@ -405,24 +1527,10 @@ func (f *writeFuncBodyMakerV1) Make() error {
 func (f *writeFuncBodyMakerV1) build() {
 	var builder funcBodyWriter
 
-	if len(f.function.Results()) == 1 {
-		builder.FWrite(
-			"_, err := juice.ExecContext(%s, %s.%s, %s)",
-			f.function.Params().NameAt(ast.ParamPrefix, 0),
-			fmt.Sprintf("%s(%s)", f.function.typename, f.function.receiverAlias()),
-			f.function.Name(),
-			formatParams(f.function.Params()),
-		)
-		builder.FWrite("return err")
-	} else {
-		builder.FWrite(
-			"return juice.ExecContext(%s, %s.%s, %s)",
-			f.function.Params().NameAt(ast.ParamPrefix, 0),
-			fmt.Sprintf("%s(%s)", f.function.typename, f.function.receiverAlias()),
-			f.function.Name(),
-			formatParams(f.function.Params()),
-		)
-	}
+	f.function.writeAllowed(&builder)
+	f.writeGuards(&builder)
+
+	f.buildExec(&builder)
 
 	f.function.body = formatCode(builder.String())
 }
@@ -442,53 +1550,64 @@ func (f *writeFuncBodyMakerV2) Make() error {
 func (f *writeFuncBodyMakerV2) build() {
 	var builder funcBodyWriter
 
+	f.function.writeAllowed(&builder)
+	f.writeGuards(&builder)
+
 	builder.FWrite("%s = juice.ContextWithManager(%s, %s.manager)",
 		f.function.Params().NameAt(ast.ParamPrefix, 0),
 		f.function.Params().NameAt(ast.ParamPrefix, 0),
 		f.function.receiverAlias(),
 	)
 
-	if len(f.function.Results()) == 1 {
-		builder.FWrite(
-			"_, err := juice.ExecContext(%s, %s.%s, %s)",
-			f.function.Params().NameAt(ast.ParamPrefix, 0),
-			fmt.Sprintf("%s(%s)", f.function.typename, f.function.receiverAlias()),
-			f.function.Name(),
-			formatParams(f.function.Params()),
-		)
-		builder.FWrite("return err")
-	} else {
-		builder.FWrite(
-			"return juice.ExecContext(%s, %s.%s, %s)",
-			f.function.Params().NameAt(ast.ParamPrefix, 0),
-			fmt.Sprintf("%s(%s)", f.function.typename, f.function.receiverAlias()),
-			f.function.Name(),
-			formatParams(f.function.Params()),
-		)
-	}
+	f.buildExec(&builder)
 
 	f.function.body = formatCode(builder.String())
 }
 
+// stubFuncBodyMaker makes the body of the function whose statement is not defined,
+// which returns an error wrapping juice.ErrNoStatementFound.
+type stubFuncBodyMaker struct {
+	statementName string
+	function      *Function
+}
+
+func (f *stubFuncBodyMaker) Make() error {
+	results := f.function.Results()
+	if len(results) == 0 || !isError(results[len(results)-1]) {
+		return fmt.Errorf("%s: statement %s not defined, and the last result must be error to generate a stub", f.function.Name(), f.statementName)
+	}
+	var builder funcBodyWriter
+	builder.FWrite(
+		"%s = fmt.Errorf(\"%%w: %%s\", juice.ErrNoStatementFound, %q)",
+		results.NameAt(ast.ResultPrefix, len(results)-1),
+		f.statementName,
+	)
+	builder.FWrite("return")
+	f.function.body = formatCode(builder.String())
+	return nil
+}
+
 func formatParams(params ast.ValueGroup) string {
 	switch len(params) {
 	case 0, 1:
 		return "nil"
 	case 2:
 		param1 := params[1]
-		if param1.IsBuiltInType() {
-			return fmt.Sprintf(`juice.H{"%s": %s}`, param1.Name(), param1.Name())
+		name := params.NameAt(ast.ParamPrefix, 1)
+		if isScalar(param1) || isNullable(param1) || isSlice(param1) {
+			return fmt.Sprintf(`juice.H{"%s": %s}`, name, name)
 		}
 		switch param1.Field.Type.(type) {
-		case *stdast.ArrayType:
-			return fmt.Sprintf(`juice.H{"%s": %s}`, param1.Name(), param1.Name())
+		case *stdast.ArrayType, *stdast.Ellipsis:
+			return fmt.Sprintf(`juice.H{"%s": %s}`, name, name)
 		}
-		return param1.Name()
+		return name
 	default:
 		var builder strings.Builder
 		builder.WriteString("juice.H{")
-		for index, param := range params[1:] {
-			builder.WriteString(fmt.Sprintf("%q: %s", param.Name(), param.Name()))
+		for index := range params[1:] {
+			name := params.NameAt(ast.ParamPrefix, index+1)
+			builder.WriteString(fmt.Sprintf("%q: %s", name, name))
 			if index < len(params)-2 {
 				builder.WriteString(", ")
 			}
@@ -0,0 +1,42 @@
+package internal
+
+import "testing"
+
+func TestFormatSQL(t *testing.T) {
+	tests := []struct {
+		name, raw, expected string
+	}{
+		{
+			name:     "indent",
+			raw:      "\n        select * from user\n        <where>\n            id = #{id}\n        </where>\n    ",
+			expected: "select * from user\n<where>\n    id = #{id}\n</where>",
+		},
+		{
+			name:     "first line",
+			raw:      "select *\n        from user\n    ",
+			expected: "select *\nfrom user",
+		},
+		{
+			name:     "escapes",
+			raw:      "\n        select * from user where age &gt;= #{min} and age &lt; #{max} and name &lt;&gt; &apos;&amp;&apos;\n    ",
+			expected: "select * from user where age >= #{min} and age < #{max} and name <> '&'",
+		},
+		{
+			name:     "cdata",
+			raw:      "\n        <![CDATA[\n        select * from user where age < #{max} and note = '&lt;'\n        ]]>\n    ",
+			expected: "select * from user where age < #{max} and note = '&lt;'",
+		},
+		{
+			name:     "cdata inline",
+			raw:      "\n        select * from user where <![CDATA[ age < #{max} ]]> and id &gt; 0\n    ",
+			expected: "select * from user where  age < #{max}  and id > 0",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if result := formatSQL(tt.raw); result != tt.expected {
+				t.Errorf("expected:\n%s\ngot:\n%s", tt.expected, result)
+			}
+		})
+	}
+}
@@ -0,0 +1,202 @@
+package internal
+
+import (
+	"flag"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/go-juicedev/juice"
+	astlite "github.com/go-juicedev/juicecli/internal/ast"
+	"github.com/go-juicedev/juicecli/internal/diff"
+	"github.com/go-juicedev/juicecli/internal/mapper"
+	"github.com/go-juicedev/juicecli/internal/module"
+	"github.com/go-juicedev/juicecli/internal/namespace"
+)
+
+var update = flag.Bool("update", false, "update the golden files in testdata")
+
+// goldenHeader is the header of the golden files, which does not record the command line of the test.
+const goldenHeader = headerPrefix + "\"juicecli impl\"; DO NOT EDIT.\n"
+
+// fixture is an interface declared in testdata to generate the implementation for.
+type fixture struct {
+	// dir is the directory of the package which declares the interface, relative to testdata.
+	dir      string
+	typename string
+	version  string
+	// pkg is the directory of the package which the implementation is generated into, relative to testdata,
+	// empty for the package of the interface.
+	pkg     string
+	partial bool
+	withSQL bool
+}
+
+// generate generates the implementation of the fixture like the impl command does, without the header.
+// The Implement reports the package and the declarations the implementation requires.
+func (f fixture) generate(cfg juice.Configuration, index *mapper.Index) (Implement, string, error) {
+	dir := filepath.Join("testdata", f.dir)
+	node, err := module.FindInterfaceNode(dir, f.typename)
+	if err != nil {
+		return nil, "", err
+	}
+	pkgTypes, err := module.LoadTypes(dir)
+	if err != nil {
+		return nil, "", err
+	}
+	iface := &astlite.Interface{InterfaceType: node.Type, TypeParams: node.TypeParams, Name: node.Name, Dir: dir, File: node.File, Doc: node.Doc, Types: pkgTypes}
+	ns, err := namespace.OfInterface(iface)
+	if err != nil {
+		return nil, "", err
+	}
+	pkg, outputDir := "", dir
+	if f.pkg != "" {
+		importPath, err := module.ImportPath(dir)
+		if err != nil {
+			return nil, "", err
+		}
+		iface = iface.Qualify(node.File.Name.Name, importPath)
+		pkg, outputDir = filepath.Base(f.pkg), filepath.Join("testdata", f.pkg)
+	}
+	impl, err := NewImplement(iface, cfg, index, ns, f.version, f.typename+"Impl", pkg, outputDir, f.partial, f.withSQL)
+	if err != nil {
+		return nil, "", err
+	}
+	code, err := render(impl)
+	return impl, code, err
+}
+
+// render generates the code of the implementation without the header.
+func render(impl Implement) (string, error) {
+	reader, err := NewGenerator(impl).Generate()
+	if err != nil {
+		return "", err
+	}
+	code, err := io.ReadAll(reader)
+	if err != nil {
+		return "", err
+	}
+	return StripHeader(string(code)), nil
+}
+
+func loadFixtureConfig(t *testing.T) (juice.Configuration, *mapper.Index) {
+	t.Helper()
+	parser := NewParser("").WithConfig(filepath.Join("testdata", "juice.xml"))
+	cfg, err := parser.Config()
+	if err != nil {
+		t.Fatalf("failed to load configuration: %v", err)
+	}
+	index, err := parser.Mappers()
+	if err != nil {
+		t.Fatalf("failed to load mappers: %v", err)
+	}
+	return cfg, index
+}
+
+// TestGenerate compares the generated implementations with the golden files in testdata,
+// which are compiled and vetted with the interfaces. Run go test -update to rewrite the golden files.
+func TestGenerate(t *testing.T) {
+	cfg, index := loadFixtureConfig(t)
+	tests := []struct {
+		fixture
+		golden string
+		// missing are the statements whose methods are generated as stubs
+		missing []string
+	}{
+		{fixture{dir: "repo", typename: "UserRepository", version: v2}, "repo/user_repository_impl.go", nil},
+		{fixture{dir: "repo", typename: "OrderRepository", version: v1, withSQL: true}, "repo/order_repository_impl.go", nil},
+		{fixture{dir: "repo", typename: "AuditRepository", version: v2}, "repo/audit_repository_impl.go", nil},
+		{fixture{dir: "repo", typename: "AuditRepository", version: v2, pkg: "store"}, "store/audit_repository_impl.go", nil},
+		{fixture{dir: "repo", typename: "AccountRepository", version: v2}, "repo/account_repository_impl.go", nil},
+		{fixture{dir: "repo", typename: "ProductRepository", version: v2}, "repo/product_repository_impl.go", nil},
+		{fixture{dir: "repo", typename: "Repository", version: v2}, "repo/repository_impl.go", nil},
+		{fixture{dir: "repo", typename: "DraftRepository", version: v2, partial: true}, "repo/draft_repository_impl.go", []string{"repo.DraftRepository.Publish"}},
+	}
+	// rowCountErrors are the packages which require the RowCountErrorFile, by their directories
+	rowCountErrors := make(map[string]string)
+	for _, tt := range tests {
+		t.Run(tt.golden, func(t *testing.T) {
+			impl, code, err := tt.generate(cfg, index)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if missing := impl.Missing(); !slices.Equal(missing, tt.missing) {
+				t.Errorf("expected stubs of %v, got %v", tt.missing, missing)
+			}
+			if impl.RowCountError() {
+				rowCountErrors[filepath.Dir(tt.golden)] = impl.Package()
+			}
+			compareGolden(t, tt.golden, code)
+		})
+	}
+	if len(rowCountErrors) == 0 {
+		t.Error("expected the fixtures to require the row count error")
+	}
+	for dir, pkg := range rowCountErrors {
+		golden := filepath.Join(dir, RowCountErrorFile)
+		t.Run(golden, func(t *testing.T) {
+			code, err := render(NewRowCountErrorFile(pkg))
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			compareGolden(t, golden, code)
+		})
+	}
+	cmd := exec.Command("go", "vet", "./repo", "./store")
+	cmd.Dir = "testdata"
+	if output, err := cmd.CombinedOutput(); err != nil {
+		t.Fatalf("go vet: %v\n%s", err, output)
+	}
+}
+
+// compareGolden compares the generated code with the golden file relative to testdata, or updates the golden file.
+func compareGolden(t *testing.T, golden, code string) {
+	t.Helper()
+	golden = filepath.Join("testdata", golden)
+	if *update {
+		if err := os.WriteFile(golden, []byte(goldenHeader+code), 0o644); err != nil {
+			t.Fatalf("failed to update golden file: %v", err)
+		}
+		return
+	}
+	want, err := os.ReadFile(golden)
+	if err != nil {
+		t.Fatalf("failed to read golden file: %v", err)
+	}
+	if result := diff.Unified(golden, golden+" (generated)", StripHeader(string(want)), code); result != "" {
+		t.Errorf("generated code differs from the golden file, run go test -update if expected:\n%s", result)
+	}
+}
+
+// TestGenerate_Errors checks the errors of the interfaces in testdata/invalid,
+// one for each interface, whose statements are in testdata/mapper/invalid.
+func TestGenerate_Errors(t *testing.T) {
+	cfg, index := loadFixtureConfig(t)
+	tests := []struct {
+		typename string
+		err      string
+	}{
+		{"AllowedType", "`List` `allow-sort` requires sort to be a string"},
+		{"AllowedBinding", "`ByName` `allow-name` requires name to be used by ${name} in the statement"},
+		{"KeyNotMap", "`List` `key` requires List to return (map[K]T, error) indexed by the key column"},
+		{"NotFound", "`Get` `notFound` must be nil or the name of an error variable declared in package invalid, but got ErrNotFound"},
+		{"Returning", "`Create` has a RETURNING clause, but Create is executed without querying the returned rows"},
+		{"BatchSize", "`CreateAll` `batchSize` requires a slice as the only parameter besides context.Context"},
+		{"CountRef", "`Page` `countRef` statement Count not found"},
+		{"KeyProperty", "`Create` `keyProperty` Email: no field of User is named Email"},
+		{"WithTx", "WithTx: must be declared as WithTx(tx juice.TxManager) WithTx to be generated"},
+		{"Unsupplied", "methods not generated: Multi, declare them on UnsuppliedImpl or on the type unsuppliedCustom in package invalid"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.typename, func(t *testing.T) {
+			_, _, err := fixture{dir: "invalid", typename: tt.typename, version: v2}.generate(cfg, index)
+			if err == nil || !strings.Contains(err.Error(), tt.err) {
+				t.Errorf("expected error containing %q, got %v", tt.err, err)
+			}
+		})
+	}
+}
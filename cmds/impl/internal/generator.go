@@ -7,6 +7,9 @@ import (
 	"strings"
 )
 
+// headerPrefix is the prefix of the first line of the generated code.
+const headerPrefix = "// Code generated by "
+
 type Generator struct {
 	impl Implement
 }
@@ -14,7 +17,7 @@ type Generator struct {
 func (g *Generator) Generate() (io.Reader, error) {
 	builder := strings.Builder{}
 	args := strings.Join(os.Args[:], " ")
-	builder.WriteString(fmt.Sprintf("// Code generated by \"%s\"; DO NOT EDIT.", args))
+	builder.WriteString(fmt.Sprintf("%s\"%s\"; DO NOT EDIT.", headerPrefix, args))
 	builder.WriteString("\n\n")
 	data, err := g.impl.Render()
 	if err != nil {
@@ -36,3 +39,16 @@ func (g *Generator) WriteTo(writer io.Writer) (int64, error) {
 func NewGenerator(impl Implement) *Generator {
 	return &Generator{impl: impl}
 }
+
+// StripHeader returns the generated code without its header line.
+// The header records the command line which generated the code,
+// which differs between runs that generate the same code.
+func StripHeader(code string) string {
+	if !strings.HasPrefix(code, headerPrefix) {
+		return code
+	}
+	if index := strings.Index(code, "\n"); index >= 0 {
+		return code[index+1:]
+	}
+	return ""
+}
@@ -1,19 +1,54 @@
 package internal
 
 import (
+	"errors"
 	"fmt"
 	"go/ast"
 	"go/parser"
 	"go/token"
+	"go/types"
 	"log"
+	"path/filepath"
+	"slices"
 	"strings"
+	"unicode"
+	"unicode/utf8"
 
 	`github.com/go-juicedev/juice`
 	astlite "github.com/go-juicedev/juicecli/internal/ast"
+	"github.com/go-juicedev/juicecli/internal/mapper"
+	"github.com/go-juicedev/juicecli/internal/module"
+	"github.com/go-juicedev/juicecli/internal/namespace"
 )
 
 type Implement interface {
 	Render() (string, error)
+	// Package returns the name of the package which the implementation is generated into.
+	Package() string
+	// Missing returns the full names of the statements which are not defined,
+	// whose methods are generated as stubs in partial mode.
+	Missing() []string
+	// Deprecated returns the methods generated from deprecated statements,
+	// each with the full name of the statement and the deprecation message.
+	Deprecated() []string
+	// RowCountError reports whether the implementation requires ErrUnexpectedRowCount generated into
+	// RowCountErrorFile of its package, which is when a method checks the affected rows,
+	// unless the package declares it by hand.
+	RowCountError() bool
+}
+
+// statementNotFoundError is returned when the statement of a method is not defined.
+type statementNotFoundError struct {
+	name string
+	err  error
+}
+
+func (e *statementNotFoundError) Error() string {
+	return e.err.Error()
+}
+
+func (e *statementNotFoundError) Unwrap() error {
+	return e.err
 }
 
 type implement struct {
@@ -24,50 +59,414 @@ type implement struct {
 	methods                   FunctionGroup
 	src, dst                  string
 	namespace                 string
+	pkg                       string
 	functionBodyMakerProvider FunctionBodyMakerProvider
+	// partial generates stubs for the methods whose statement is not defined instead of failing.
+	partial bool
+	missing []string
+	// deprecated are the methods generated from deprecated statements, see Implement.Deprecated.
+	deprecated []string
+	// withSQL appends the SQL of the statements to the doc comments of the methods.
+	withSQL bool
+	// dir is the directory of the package which the implementation is generated into.
+	dir string
+	// custom is the name of the user-declared type embedded by the implementation, empty if not declared.
+	custom string
+	// customManager reports whether the custom type has the field manager of juice.Manager,
+	// which is set to the manager of the implementation.
+	customManager bool
+	// handWritten are the names of the methods written by hand in the package of the implementation.
+	handWritten []string
+	// reserved are the names of the methods generated by the implementation itself instead of from statements.
+	reserved []string
+	// declaredReserved are the reserved methods declared by the interface, which are the only ones generated.
+	declaredReserved []string
+	// rowCountError reports whether the implementation requires errUnexpectedRowCount generated, see Implement.RowCountError.
+	rowCountError bool
+	// index is the index of the mapper files, which keeps the raw SQL of the statements.
+	index *mapper.Index
+}
+
+// errUnexpectedRowCount is the name of the error returned when a statement with the `expectRows` attribute
+// affects an unexpected number of rows.
+const errUnexpectedRowCount = "ErrUnexpectedRowCount"
+
+func (i *implement) Missing() []string {
+	return i.missing
+}
+
+func (i *implement) Deprecated() []string {
+	return i.deprecated
+}
+
+func (i *implement) RowCountError() bool {
+	return i.rowCountError
 }
 
 func (i *implement) Package() string {
+	if i.pkg != "" {
+		return i.pkg
+	}
 	return i.file.Name.Name
 }
 
+// typename returns the type name of the interface used in the generated code.
+func (i *implement) typename() string {
+	return i.iface.QualifiedName() + i.iface.TypeArgs()
+}
+
+// Imports returns the imports required by the generated methods.
 func (i *implement) Imports() astlite.ImportGroup {
-	return append(i.iface.Imports(i.file.Imports), i.extraImports...).Uniq()
+	var imports astlite.ImportGroup
+	for _, method := range i.methods {
+		imports = append(imports, method.method.Imports(i.file.Imports)...)
+		imports = append(imports, method.imports...)
+	}
+	imports = append(imports, i.iface.TypeParamImports(i.file.Imports)...)
+	if imp := i.iface.PackageImport(); imp != nil {
+		imports = append(imports, imp)
+	}
+	if len(i.missing) > 0 {
+		imports = append(imports, &astlite.Import{ImportSpec: extraImport.Imports[1]})
+	}
+	return append(imports, i.extraImports...).Uniq()
+}
+
+// statement returns the statement of the method, see namespace.Statement.
+func (i *implement) statement(method *astlite.Function) (juice.Statement, error) {
+	name, _, err := namespace.Statement(i.namespace, method, func(name string) bool {
+		_, err := i.cfg.GetStatement(name)
+		return err == nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", method.Name(), err)
+	}
+	statement, err := i.cfg.GetStatement(name)
+	if err != nil {
+		return nil, &statementNotFoundError{name: name, err: err}
+	}
+	return statement, nil
+}
+
+// source returns the statement declared in the mapper file, nil if the mapper file is not loaded, e.g. by url.
+func (i *implement) source(statement juice.Statement) *mapper.Statement {
+	if i.index == nil {
+		return nil
+	}
+	m, ok := i.index.Mapper(strings.TrimSuffix(statement.Name(), "."+statement.ID()))
+	if !ok {
+		return nil
+	}
+	source, _ := m.Statement(statement.ID())
+	return source
+}
+
+// customTypeName returns the name of the type which the user may declare in the package of the implementation
+// to supply the methods which are not generated, e.g. UserRepository => userRepositoryCustom.
+func (i *implement) customTypeName() string {
+	r, size := utf8.DecodeRuneInString(i.src)
+	return string(unicode.ToLower(r)) + i.src[size:] + "Custom"
+}
+
+// structFields returns the fields of the implementation struct.
+func (i *implement) structFields() string {
+	if i.custom == "" {
+		return ""
+	}
+	return i.custom + i.iface.TypeArgs()
+}
+
+// handWrittenMethods returns the names of the methods written by hand in the package of the implementation,
+// which are declared on the implementation itself or on the custom type it embeds.
+func (i *implement) handWrittenMethods() ([]string, error) {
+	if i.dir == "" {
+		return nil, nil
+	}
+	names, err := module.FindMethodNames(i.dir, i.dst)
+	if err != nil {
+		return nil, err
+	}
+	custom := i.customTypeName()
+	declared, err := module.IsTypeDeclared(i.dir, custom)
+	if err != nil || !declared {
+		return names, err
+	}
+	i.custom = custom
+	customNames, err := module.FindMethodNames(i.dir, custom)
+	if err != nil {
+		return nil, err
+	}
+	return append(names, customNames...), nil
 }
 
 func (i *implement) buildFunction() error {
-	for _, m := range i.iface.Methods() {
-		method := m
-		key := fmt.Sprintf("%s.%s", i.namespace, method.Name())
-		statement, err := i.cfg.GetStatement(key)
+	methods, err := i.iface.Methods()
+	if err != nil {
+		return err
+	}
+	if i.handWritten, err = i.handWrittenMethods(); err != nil {
+		return err
+	}
+	// skipped are the methods which are neither generated nor written by hand
+	var skipped []string
+	// the namespace which juice derives from the interface method
+	cmp := namespace.AutoComplete{TypeName: i.iface.Name, Dir: i.iface.Dir}
+	implicitNamespace, err := cmp.Autocomplete()
+	if err != nil {
+		return err
+	}
+	for _, method := range methods {
+		if slices.Contains(i.handWritten, method.Name()) {
+			continue
+		}
+		if slices.Contains(i.reserved, method.Name()) {
+			if err = i.checkWithTx(method); err != nil {
+				return err
+			}
+			i.declaredReserved = append(i.declaredReserved, method.Name())
+			continue
+		}
+		if _, ok := method.Directive("skip"); ok {
+			skipped = append(skipped, method.Name())
+			continue
+		}
+		if _, err = method.ParamNames(); err != nil {
+			return fmt.Errorf("%s: %w", method.Name(), err)
+		}
+		statement, err := i.statement(method)
+		var notFound *statementNotFoundError
+		if i.partial && errors.As(err, &notFound) {
+			function := &Function{method: method, receiver: i.dst + i.iface.TypeArgs(), typename: i.typename(), iface: i.iface}
+			maker := &stubFuncBodyMaker{statementName: notFound.name, function: function}
+			if err = maker.Make(); err != nil {
+				return err
+			}
+			i.methods = append(i.methods, function)
+			i.missing = append(i.missing, notFound.name)
+			continue
+		}
 		if err != nil {
 			return err
 		}
 		if statement.Attribute("gen") == "false" || statement.Attribute("generate") == "false" { // skip
+			skipped = append(skipped, method.Name())
 			continue
 		}
-		function := &Function{method: method, receiver: i.dst, typename: i.src}
+		function := &Function{method: method, receiver: i.dst + i.iface.TypeArgs(), typename: i.typename(), iface: i.iface, source: i.source(statement)}
+		if i.withSQL && function.source != nil {
+			function.sql = formatSQL(function.source.SQL)
+		}
+		if message := statement.Attribute("deprecated"); message != "" {
+			function.deprecated = message
+			i.deprecated = append(i.deprecated, fmt.Sprintf("%s (%s): %s", method.Name(), statement.Name(), message))
+		}
+		// juice finds the statement by the name of the interface method,
+		// otherwise the full name of the statement is required.
+		// The name of a generic interface method can not be recognized by juice neither.
+		if statement.Name() != fmt.Sprintf("%s.%s", implicitNamespace, method.Name()) || i.iface.TypeParams != nil {
+			function.statementName = statement.Name()
+		}
 		maker := i.functionBodyMakerProvider(statement, function)
 		if err = maker.Make(); err != nil {
 			return err
 		}
 		i.methods = append(i.methods, function)
 	}
+	if err = i.checkCustom(skipped); err != nil {
+		return err
+	}
+	return i.checkRowCountError()
+}
 
+// checkCustom checks that the skipped methods are supplied by the custom type,
+// either declared on it or promoted from its embedded fields,
+// and decides whether the custom type receives the manager of the implementation.
+func (i *implement) checkCustom(skipped []string) error {
+	if i.custom == "" {
+		if len(skipped) > 0 {
+			return fmt.Errorf("methods not generated: %s, declare them on %s or on the type %s in package %s",
+				strings.Join(skipped, ", "), i.dst, i.customTypeName(), i.Package())
+		}
+		return nil
+	}
+	pkg, err := i.outputTypes()
+	if err != nil {
+		return err
+	}
+	obj, ok := pkg.Scope().Lookup(i.custom).(*types.TypeName)
+	if !ok {
+		return fmt.Errorf("can not find the type %s in package %s", i.custom, i.Package())
+	}
+	// the implementation embeds the custom type by value and is used by pointer
+	custom := types.NewPointer(obj.Type())
+	var missing []string
+	for _, name := range skipped {
+		method, _, _ := types.LookupFieldOrMethod(custom, false, pkg, name)
+		if _, ok = method.(*types.Func); !ok {
+			missing = append(missing, name)
+		}
+	}
+	if len(missing) > 0 {
+		return fmt.Errorf("methods not generated: %s, declare them on %s or on the type %s in package %s",
+			strings.Join(missing, ", "), i.dst, i.custom, i.Package())
+	}
+	field, _, _ := types.LookupFieldOrMethod(obj.Type(), false, pkg, "manager")
+	if v, ok := field.(*types.Var); ok && v.IsField() {
+		i.customManager = isNamedType(v.Type(), "github.com/go-juicedev/juice", "Manager")
+	}
 	return nil
 }
 
-func NewImplement(writer *ast.File, iface *ast.InterfaceType, cfg juice.Configuration, namespace, version, input, output string) (Implement, error) {
+// outputTypes returns the type-checked package which the implementation is generated into.
+func (i *implement) outputTypes() (*types.Package, error) {
+	if i.iface.Types != nil && i.pkg == "" {
+		dir, err := filepath.Abs(i.dir)
+		if err != nil {
+			return nil, err
+		}
+		ifaceDir, err := filepath.Abs(i.iface.Dir)
+		if err != nil {
+			return nil, err
+		}
+		if dir == ifaceDir {
+			return i.iface.Types, nil
+		}
+	}
+	return module.LoadTypes(i.dir)
+}
+
+// checkWithTx checks that the WithTx declared by the interface has the signature of the generated one,
+// which is WithTx(tx juice.TxManager) followed by the interface itself.
+func (i *implement) checkWithTx(method *astlite.Function) error {
+	function := &Function{method: method, iface: i.iface}
+	params, results := method.Params(), method.Results()
+	if len(params) != 1 || !function.isNamed(params[0], "github.com/go-juicedev/juice", "TxManager") ||
+		len(results) != 1 || !i.isInterface(results[0]) {
+		return fmt.Errorf("%s: must be declared as WithTx(tx juice.TxManager) %s to be generated", method.Name(), i.iface.Name+i.iface.TypeArgs())
+	}
+	return nil
+}
+
+// isInterface reports whether the value is of the interface which the implementation is generated for.
+func (i *implement) isInterface(value *astlite.Value) bool {
+	if t := value.Resolved(); t != nil {
+		return i.iface.Types != nil && isNamedType(t, i.iface.Types.Path(), i.iface.Name)
+	}
+	expr := value.Type
+	switch index := expr.(type) {
+	case *ast.IndexExpr:
+		expr = index.X
+	case *ast.IndexListExpr:
+		expr = index.X
+	}
+	ident, ok := expr.(*ast.Ident)
+	return ok && ident.Name == i.iface.Name
+}
+
+// checkRowCountError decides whether the implementation requires errUnexpectedRowCount generated.
+// It is generated into its own file, so that the implementations of several interfaces in a package share it.
+func (i *implement) checkRowCountError() error {
+	if !slices.ContainsFunc(i.methods, func(f *Function) bool { return f.checksRowCount }) {
+		return nil
+	}
+	declared, err := module.IsValueDeclared(i.dir, errUnexpectedRowCount)
+	if err != nil {
+		return err
+	}
+	i.rowCountError = !declared
+	return nil
+}
+
+// timeoutRows returns the declaration of the type which wraps the rows to cancel the timeout when closed,
+// empty if no method returns such rows.
+func (i *implement) timeoutRows() string {
+	index := slices.IndexFunc(i.methods, func(f *Function) bool { return f.timeoutRows != "" })
+	if index < 0 {
+		return ""
+	}
+	name := timeoutRowsTypeName(i.dst)
+	var builder strings.Builder
+	builder.WriteString(fmt.Sprintf("// %s cancels the timeout of the query when the rows are closed.\n", name))
+	builder.WriteString(fmt.Sprintf("type %s struct {\n%s\ncancel context.CancelFunc\n}", name, i.methods[index].timeoutRows))
+	builder.WriteString("\n\n")
+	builder.WriteString(fmt.Sprintf("func (r *%s) Close() error {\n", name))
+	builder.WriteString("\tdefer r.cancel()\n")
+	builder.WriteString("\treturn r.Rows.Close()\n")
+	builder.WriteString("}")
+	return builder.String()
+}
+
+// RowCountErrorFile is the name of the file which declares ErrUnexpectedRowCount,
+// generated once into each package whose implementations require it, see Implement.RowCountError.
+const RowCountErrorFile = "juice_errors_impl.go"
+
+// rowCountErrorFile is the Implement of RowCountErrorFile.
+type rowCountErrorFile struct {
+	pkg string
+}
+
+// NewRowCountErrorFile returns the Implement of RowCountErrorFile of the package with the name.
+func NewRowCountErrorFile(pkg string) Implement {
+	return &rowCountErrorFile{pkg: pkg}
+}
+
+func (r *rowCountErrorFile) Render() (string, error) {
+	var builder strings.Builder
+	builder.WriteString(fmt.Sprintf("package %s", r.pkg))
+	builder.WriteString("\n\n")
+	builder.WriteString(astlite.ImportGroup{&astlite.Import{ImportSpec: extraImport.Imports[4]}}.String())
+	builder.WriteString("\n\n")
+	builder.WriteString(fmt.Sprintf("// %s is returned when a statement affects an unexpected number of rows.\n", errUnexpectedRowCount))
+	builder.WriteString(fmt.Sprintf("var %s = errors.New(\"unexpected row count\")", errUnexpectedRowCount))
+	return formatCode(builder.String()), nil
+}
+
+func (r *rowCountErrorFile) Package() string {
+	return r.pkg
+}
+
+func (r *rowCountErrorFile) Missing() []string {
+	return nil
+}
+
+func (r *rowCountErrorFile) Deprecated() []string {
+	return nil
+}
+
+func (r *rowCountErrorFile) RowCountError() bool {
+	return false
+}
+
+// declarations returns the package-level declarations required by the methods.
+func (i *implement) declarations() string {
+	return i.timeoutRows()
+}
+
+// NewImplement returns an Implement of the interface.
+// The index is the index of the mapper files of cfg, which is used to inspect the SQL of the statements.
+// The pkg is the name of the package which the implementation is generated into,
+// empty means the package of the interface.
+// The dir is the directory of the package which the implementation is generated into,
+// the methods written by hand in that package are not generated.
+// If partial is true, the methods whose statement is not defined are generated as stubs
+// which return an error wrapping juice.ErrNoStatementFound.
+// If withSQL is true, the SQL of the statements is appended to the doc comments of the methods.
+func NewImplement(iface *astlite.Interface, cfg juice.Configuration, index *mapper.Index, namespace, version, output, pkg, dir string, partial, withSQL bool) (Implement, error) {
 	impl := &implement{
 		dst:   output,
 		cfg:   cfg,
-		src:   input,
-		file:  writer,
-		iface: &astlite.Interface{InterfaceType: iface},
+		index: index,
+		src:   iface.Name,
+		file:  iface.File,
+		iface: iface,
 		extraImports: astlite.ImportGroup{
 			&astlite.Import{ImportSpec: extraImport.Imports[0]},
 		},
 		namespace: namespace,
+		pkg:       pkg,
+		partial:   partial,
+		withSQL:   withSQL,
+		dir:       dir,
 	}
 
 	switch version {
@@ -81,6 +480,7 @@ func NewImplement(writer *ast.File, iface *ast.InterfaceType, cfg juice.Configur
 						readFuncBodyMaker: &readFuncBodyMaker{
 							statement: statement,
 							function:  function,
+							cfg:       cfg,
 						}}
 				},
 				writeFuncBodyMakerProvider: func(statement juice.Statement, function *Function) FunctionBodyMaker {
@@ -104,6 +504,7 @@ func NewImplement(writer *ast.File, iface *ast.InterfaceType, cfg juice.Configur
 						readFuncBodyMaker: &readFuncBodyMaker{
 							statement: statement,
 							function:  function,
+							cfg:       cfg,
 						}}
 				},
 				writeFuncBodyMakerProvider: func(statement juice.Statement, function *Function) FunctionBodyMaker {
@@ -116,6 +517,8 @@ func NewImplement(writer *ast.File, iface *ast.InterfaceType, cfg juice.Configur
 				},
 			}
 		}
+		impl.extraImports = append(impl.extraImports, &astlite.Import{ImportSpec: extraImport.Imports[2]})
+		impl.reserved = []string{"WithTx"}
 		return &ImplementV2{implement: impl}, nil
 	default:
 		return nil, fmt.Errorf("unsupported version: %s", version)
@@ -129,9 +532,9 @@ type ImplementV1 struct {
 func (i *ImplementV1) constructor() string {
 	var builder strings.Builder
 	builder.WriteString(fmt.Sprintf("// New%s returns a new %s.\n", i.src, i.src))
-	builder.WriteString(fmt.Sprintf("func New%s() %s {", i.src, i.src))
+	builder.WriteString(fmt.Sprintf("func New%s%s() %s {", i.src, i.iface.TypeParamDecl(), i.typename()))
 	builder.WriteString("\n\t")
-	builder.WriteString(fmt.Sprintf("return &%s{}", i.dst))
+	builder.WriteString(fmt.Sprintf("return &%s%s{}", i.dst, i.iface.TypeArgs()))
 	builder.WriteString("\n")
 	builder.WriteString("}")
 	return builder.String()
@@ -147,8 +550,12 @@ func (i *ImplementV1) Render() (string, error) {
 	builder.WriteString("\n\n")
 	builder.WriteString(i.Imports().String())
 	builder.WriteString("\n\n")
-	builder.WriteString(fmt.Sprintf("type %s struct {}", i.dst))
+	builder.WriteString(fmt.Sprintf("type %s%s struct { %s }", i.dst, i.iface.TypeParamDecl(), i.structFields()))
 	builder.WriteString("\n\n")
+	if declarations := i.declarations(); declarations != "" {
+		builder.WriteString(declarations)
+		builder.WriteString("\n\n")
+	}
 	// implement methods
 	builder.WriteString(i.methods.String())
 	builder.WriteString("\n\n")
@@ -163,9 +570,13 @@ type ImplementV2 struct {
 func (i *ImplementV2) constructor() string {
 	var builder strings.Builder
 	builder.WriteString(fmt.Sprintf("// New%s returns a new %s.\n", i.src, i.src))
-	builder.WriteString(fmt.Sprintf("func New%s(manager juice.Manager) %s {", i.src, i.src))
+	builder.WriteString(fmt.Sprintf("func New%s%s(manager juice.Manager) %s {", i.src, i.iface.TypeParamDecl(), i.typename()))
 	builder.WriteString("\n\t")
-	builder.WriteString(fmt.Sprintf("return &%s{manager: manager}", i.dst))
+	if i.customManager {
+		builder.WriteString(fmt.Sprintf("return &%s%s{%s: %s{manager: manager}, manager: manager}", i.dst, i.iface.TypeArgs(), i.custom, i.structFields()))
+	} else {
+		builder.WriteString(fmt.Sprintf("return &%s%s{manager: manager}", i.dst, i.iface.TypeArgs()))
+	}
 	builder.WriteString("\n")
 	builder.WriteString("}")
 	return builder.String()
@@ -181,19 +592,93 @@ func (i *ImplementV2) Render() (string, error) {
 	builder.WriteString("\n\n")
 	builder.WriteString(i.Imports().String())
 	builder.WriteString("\n\n")
-	builder.WriteString(fmt.Sprintf("type %s struct { manager juice.Manager }", i.dst))
+	builder.WriteString(fmt.Sprintf("type %s%s struct {\n%s\nmanager juice.Manager\n}", i.dst, i.iface.TypeParamDecl(), i.structFields()))
 	builder.WriteString("\n\n")
+	if declarations := i.declarations(); declarations != "" {
+		builder.WriteString(declarations)
+		builder.WriteString("\n\n")
+	}
 	// implement methods
 	builder.WriteString(i.methods.String())
 	builder.WriteString("\n\n")
+	// WithTx is only reachable through the interface, so it is generated only when the interface declares it
+	if slices.Contains(i.declaredReserved, "WithTx") {
+		builder.WriteString(i.withTx())
+		builder.WriteString("\n\n")
+	}
 	builder.WriteString(i.constructor())
+	builder.WriteString("\n\n")
+	builder.WriteString(i.runInTx())
 	return formatCode(builder.String()), nil
 }
 
+// withTx returns the method which returns a copy of the implementation using the transaction manager.
+func (i *ImplementV2) withTx() string {
+	receiver := strings.ToLower(i.dst[:1])
+	var builder strings.Builder
+	builder.WriteString("// WithTx returns a copy of the implementation which executes the statements in the transaction of tx.\n")
+	builder.WriteString(fmt.Sprintf("func (%s %s%s) WithTx(tx juice.TxManager) %s {", receiver, i.dst, i.iface.TypeArgs(), i.typename()))
+	builder.WriteString(fmt.Sprintf("\n\t%s.manager = tx", receiver))
+	if i.customManager {
+		builder.WriteString(fmt.Sprintf("\n\t%s.%s.manager = tx", receiver, i.custom))
+	}
+	builder.WriteString(fmt.Sprintf("\n\treturn &%s", receiver))
+	builder.WriteString("\n")
+	builder.WriteString("}")
+	return builder.String()
+}
+
+// runInTx returns the function which runs a handler with the implementation in a transaction.
+// It is named after the interface, since several implementations may be generated into the same package,
+// and builds the implementation with the constructor, so it does not depend on WithTx.
+func (i *ImplementV2) runInTx() string {
+	var builder strings.Builder
+	builder.WriteString(fmt.Sprintf("// Run%sInTx calls fn with an implementation of %s which executes the statements in a transaction.\n", i.src, i.src))
+	builder.WriteString("// If manager is a juice.TxManager, the transaction of it is used and left to its owner.\n")
+	builder.WriteString("// Otherwise manager must be a *juice.Engine, which begins a new transaction,\n")
+	builder.WriteString("// the transaction is committed if fn returns nil and rolled back if fn returns an error or panics.\n")
+	builder.WriteString(fmt.Sprintf("func Run%sInTx%s(ctx context.Context, manager juice.Manager, fn func(repo %s) error) (err error) {", i.src, i.iface.TypeParamDecl(), i.typename()))
+	var body funcBodyWriter
+	body.FWrite("if tx, ok := manager.(juice.TxManager); ok {")
+	body.FTabWrite(2, "return fn(New%s%s(tx))", i.src, i.iface.TypeArgs())
+	body.FWrite("}")
+	body.FWrite("engine, ok := manager.(*juice.Engine)")
+	body.FWrite("if !ok {")
+	body.FTabWrite(2, "return juice.ErrInvalidManager")
+	body.FWrite("}")
+	body.FWrite("tx := engine.ContextTx(ctx, nil)")
+	body.FWrite("if err = tx.Begin(); err != nil {")
+	body.FTabWrite(2, "return err")
+	body.FWrite("}")
+	body.FWrite("defer func() {")
+	body.FTabWrite(2, "if p := recover(); p != nil {")
+	body.FTabWrite(3, "_ = tx.Rollback()")
+	body.FTabWrite(3, "panic(p)")
+	body.FTabWrite(2, "}")
+	body.FTabWrite(2, "if err != nil {")
+	body.FTabWrite(3, "_ = tx.Rollback()")
+	body.FTabWrite(3, "return")
+	body.FTabWrite(2, "}")
+	body.FTabWrite(2, "err = tx.Commit()")
+	body.FWrite("}()")
+	body.FWrite("return fn(New%s%s(tx))", i.src, i.iface.TypeArgs())
+	builder.WriteString(body.String())
+	builder.WriteString("\n}")
+	return builder.String()
+}
+
 var extraImportSrc = `
 package main
 
-import "github.com/go-juicedev/juice"
+import (
+	"github.com/go-juicedev/juice"
+	"fmt"
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+	"database/sql/driver"
+)
 `
 
 // extraImport is an ast.File for extra import.
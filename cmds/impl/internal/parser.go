@@ -1,15 +1,13 @@
 package internal
 
 import (
-	"errors"
-	"fmt"
-	"go/ast"
 	"io"
 	"os"
-	"strings"
+	"path/filepath"
 	_ "unsafe" // for go:linkname
 
 	"github.com/go-juicedev/juice"
+	"github.com/go-juicedev/juicecli/internal/mapper"
 	"github.com/go-juicedev/juicecli/internal/module"
 	"github.com/go-juicedev/juicecli/internal/namespace"
 )
@@ -17,25 +15,17 @@ import (
 //go:linkname newLocalXMLConfiguration github.com/go-juicedev/juice.newLocalXMLConfiguration
 func newLocalXMLConfiguration(string, bool) (juice.Configuration, error)
 
-// defaultConfigFiles is the default config file name
-// while config is not set, we will check if config.xml or config/config.xml exists
-var defaultConfigFiles = [...]string{
-	"juice.xml",
-	"config/juice.xml",
-	"config.xml",
-	"config/config.xml",
-}
-
 func NewParser(typeName string) *Parser {
 	return &Parser{typename: typeName}
 }
 
 type Parser struct {
 	typename  string
-	impl      string
 	cfg       string
 	namespace string
 	output    string
+	dir       string
+	pkg       string
 }
 
 func (p *Parser) WithConfig(cfg string) *Parser {
@@ -53,25 +43,20 @@ func (p *Parser) WithOutput(output string) *Parser {
 	return p
 }
 
-func (p *Parser) WithImpl(impl string) *Parser {
-	p.impl = impl
+// WithDir sets the directory of the package which declares the interface.
+func (p *Parser) WithDir(dir string) *Parser {
+	p.dir = dir
+	return p
+}
+
+// WithPackage sets the directory of the package which the implementation is generated into.
+func (p *Parser) WithPackage(pkg string) *Parser {
+	p.pkg = pkg
 	return p
 }
 
 func (p *Parser) config() (string, error) {
-	if p.cfg != "" {
-		return p.cfg, nil
-	}
-	for _, defaultConfigFile := range defaultConfigFiles {
-		exists, err := fileExists(defaultConfigFile)
-		if err != nil {
-			return "", err
-		}
-		if exists {
-			return defaultConfigFile, nil
-		}
-	}
-	return "", errors.New(strings.Join(defaultConfigFiles[:], "|") + " not found")
+	return mapper.FindConfig(p.cfg)
 }
 
 func (p *Parser) Config() (juice.Configuration, error) {
@@ -82,37 +67,74 @@ func (p *Parser) Config() (juice.Configuration, error) {
 	return newLocalXMLConfiguration(config, true)
 }
 
-func (p *Parser) TypeInterface() (*ast.InterfaceType, *ast.File, error) {
-	node, file, err := module.FindTypeNode("./", p.typename)
+// Mappers returns the index of the mappers declared by the configuration.
+func (p *Parser) Mappers() (*mapper.Index, error) {
+	config, err := p.config()
 	if err != nil {
-		return nil, nil, fmt.Errorf("can not find type %s", p.typename)
-	}
-	iface, ok := node.(*ast.InterfaceType)
-	if !ok {
-		return nil, nil, fmt.Errorf("%s is not an interface", p.typename)
+		return nil, err
 	}
-	return iface, file, nil
+	return mapper.Load(config)
 }
 
 func (p *Parser) Output() (io.Writer, error) {
 	if p.output == "" {
 		return os.Stdout, nil
 	}
+	if err := os.MkdirAll(filepath.Dir(p.output), 0o755); err != nil {
+		return nil, err
+	}
 	return os.Create(p.output)
 }
 
+// Namespace returns the mapper namespace of the interface.
+// If the namespace is not specified, it will be auto-generated from the package of the interface.
 func (p *Parser) Namespace() (string, error) {
-	cmp := namespace.AutoComplete{TypeName: p.typename}
+	if p.namespace != "" {
+		return p.namespace, nil
+	}
+	cmp := namespace.AutoComplete{TypeName: p.typename, Dir: p.dir}
 	return cmp.Autocomplete()
 }
 
-func fileExists(path string) (bool, error) {
-	_, err := os.Stat(path)
-	if err == nil {
-		return true, nil
+// Package returns the name of the package which the implementation is generated into.
+// It returns an empty string if the package is the same as the interface's.
+func (p *Parser) Package() (string, error) {
+	if p.pkg == "" {
+		return "", nil
 	}
-	if os.IsNotExist(err) {
-		return false, nil
+	pkgDir, err := filepath.Abs(p.pkg)
+	if err != nil {
+		return "", err
+	}
+	dir, err := filepath.Abs(p.packageDir())
+	if err != nil {
+		return "", err
+	}
+	if pkgDir == dir {
+		return "", nil
+	}
+	name, err := module.GetPackageName(pkgDir)
+	if err != nil {
+		// the package does not exist yet, use the directory name as the package name
+		return filepath.Base(pkgDir), nil
+	}
+	return name, nil
+}
+
+// OutputDir returns the directory of the package which the implementation is generated into.
+func (p *Parser) OutputDir() string {
+	if p.output != "" {
+		return filepath.Dir(p.output)
+	}
+	if p.pkg != "" {
+		return p.pkg
+	}
+	return p.packageDir()
+}
+
+func (p *Parser) packageDir() string {
+	if p.dir == "" {
+		return "./"
 	}
-	return false, err
+	return p.dir
 }
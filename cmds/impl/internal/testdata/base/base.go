@@ -0,0 +1,9 @@
+// Package base declares the interfaces embedded by the interfaces of the repo package.
+package base
+
+import "context"
+
+// Finder finds the entities by their ids.
+type Finder[T any] interface {
+	Find(ctx context.Context, id int64) (*T, error)
+}
@@ -0,0 +1,66 @@
+// Package invalid declares the interfaces whose implementations can not be generated, one for each error.
+package invalid
+
+import "context"
+
+type User struct {
+	ID   int64  `column:"id"`
+	Name string `column:"name"`
+}
+
+//juice:namespace invalid.AllowedType
+type AllowedType interface {
+	List(ctx context.Context, sort int) ([]User, error)
+}
+
+//juice:namespace invalid.AllowedBinding
+type AllowedBinding interface {
+	ByName(ctx context.Context, name string) ([]User, error)
+}
+
+//juice:namespace invalid.KeyNotMap
+type KeyNotMap interface {
+	List(ctx context.Context) ([]User, error)
+}
+
+// ErrNotFound is not an error.
+var ErrNotFound = "not found"
+
+//juice:namespace invalid.NotFound
+type NotFound interface {
+	Get(ctx context.Context, id int64) (*User, error)
+}
+
+//juice:namespace invalid.Returning
+type Returning interface {
+	Create(ctx context.Context, user *User) (int64, error)
+}
+
+//juice:namespace invalid.Unsupplied
+type Unsupplied interface {
+	Get(ctx context.Context, id int64) (*User, error)
+	Multi(ctx context.Context, ids []int64) ([]User, error)
+}
+
+// unsuppliedCustom does not declare Multi, which is not generated.
+type unsuppliedCustom struct{}
+
+//juice:namespace invalid.WithTx
+type WithTx interface {
+	WithTx(ctx context.Context) error
+}
+
+//juice:namespace invalid.BatchSize
+type BatchSize interface {
+	CreateAll(ctx context.Context, user *User) (int64, error)
+}
+
+//juice:namespace invalid.CountRef
+type CountRef interface {
+	Page(ctx context.Context, limit, offset int) ([]User, int64, error)
+}
+
+//juice:namespace invalid.KeyProperty
+type KeyProperty interface {
+	Create(ctx context.Context, user *User) error
+}
@@ -0,0 +1,39 @@
+package repo
+
+import (
+	"context"
+
+	"github.com/go-juicedev/juice"
+)
+
+type Account struct {
+	ID      int64 `column:"id"`
+	Balance int64 `column:"balance"`
+}
+
+// AccountRepository covers the methods supplied by the custom type, which receives the manager.
+//
+//juice:namespace repo.AccountRepository
+type AccountRepository interface {
+	Get(ctx context.Context, id int64) (*Account, error)
+	Balance(ctx context.Context, id int64) (int64, error)
+	//juice:skip
+	Close() error
+	WithTx(tx juice.TxManager) AccountRepository
+}
+
+// accountRepositoryCustom is embedded by AccountRepositoryImpl,
+// it declares Balance by hand and supplies Close through its embedded field.
+type accountRepositoryCustom struct {
+	closer
+	manager juice.Manager
+}
+
+func (a accountRepositoryCustom) Balance(ctx context.Context, id int64) (int64, error) {
+	ctx = juice.ContextWithManager(ctx, a.manager)
+	return juice.QueryContext[int64](ctx, "repo.AccountRepository.Balance", juice.H{"id": id})
+}
+
+type closer struct{}
+
+func (closer) Close() error { return nil }
@@ -0,0 +1,27 @@
+package repo
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+// ErrAuditNotFound is returned when the audit is not found.
+var ErrAuditNotFound = errors.New("audit not found")
+
+type Audit struct {
+	ID        int64     `column:"id"`
+	UserID    int64     `column:"user_id"`
+	CreatedAt time.Time `column:"created_at"`
+}
+
+// AuditRepository covers the v2 implementation of an interface which does not declare WithTx,
+// generated into its package and into the store package.
+//
+//juice:namespace repo.AuditRepository
+type AuditRepository interface {
+	Record(ctx context.Context, audit *Audit) error
+	Get(ctx context.Context, id int64) (*Audit, error)
+	ByUser(ctx context.Context, userID int64) ([]Audit, error)
+	ByIDs(ctx context.Context, ids []int64) (map[int64]*Audit, error)
+}
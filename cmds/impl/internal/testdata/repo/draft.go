@@ -0,0 +1,16 @@
+package repo
+
+import "context"
+
+type Draft struct {
+	ID    int64  `column:"id"`
+	Title string `column:"title"`
+}
+
+// DraftRepository covers the partial mode, in which the methods without statements are generated as stubs.
+//
+//juice:namespace repo.DraftRepository
+type DraftRepository interface {
+	Get(ctx context.Context, id int64) (*Draft, error)
+	Publish(ctx context.Context, id int64) (published bool, err error)
+}
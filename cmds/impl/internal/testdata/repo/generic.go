@@ -0,0 +1,12 @@
+package repo
+
+import "context"
+
+// Repository covers the generic interfaces, whose implementation takes their type parameters.
+//
+//juice:namespace repo.Repository
+type Repository[T any, ID int64 | string] interface {
+	Get(ctx context.Context, id ID) (*T, error)
+	List(ctx context.Context) ([]T, error)
+	Delete(ctx context.Context, id ID) (int64, error)
+}
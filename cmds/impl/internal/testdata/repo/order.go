@@ -0,0 +1,25 @@
+package repo
+
+import (
+	"context"
+	"database/sql"
+)
+
+type Order struct {
+	ID     int64 `column:"id"`
+	UserID int64 `column:"user_id"`
+}
+
+// OrderRepository covers the v1 implementation, with the SQL of the statements in the comments.
+//
+//juice:namespace repo.OrderRepository
+type OrderRepository interface {
+	// Get returns the order of the id.
+	Get(ctx context.Context, id int64) (*Order, error)
+	//juice:statement Get
+	Find(ctx context.Context, id int64) (*Order, error)
+	//juice:param 1=userID
+	ByUser(context.Context, int64) ([]Order, error)
+	Create(ctx context.Context, order *Order) (sql.Result, error)
+	Cancel(ctx context.Context, id int64) (int64, error)
+}
@@ -0,0 +1,28 @@
+package repo
+
+import (
+	"context"
+
+	"github.com/go-juicedev/juicecli/cmds/impl/internal/testdata/base"
+)
+
+type Product struct {
+	ID   int64  `column:"id"`
+	Name string `column:"name"`
+}
+
+// ProductNamer is embedded by ProductRepository from the same package,
+// whose statements are under its derived namespace.
+type ProductNamer interface {
+	ByName(ctx context.Context, name string) ([]Product, error)
+}
+
+// ProductRepository covers the methods of the embedded interfaces,
+// from the same package and from a generic interface of another package.
+//
+//juice:namespace repo.ProductRepository
+type ProductRepository interface {
+	base.Finder[Product]
+	ProductNamer
+	Count(ctx context.Context) (int64, error)
+}
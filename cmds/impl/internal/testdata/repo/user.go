@@ -0,0 +1,60 @@
+package repo
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"iter"
+	"time"
+
+	"github.com/go-juicedev/juice"
+	jsql "github.com/go-juicedev/juice/sql"
+)
+
+// ErrUserNotFound is returned when the user is not found.
+var ErrUserNotFound = errors.New("user not found")
+
+type User struct {
+	ID   int64  `column:"id"`
+	Name string `column:"name"`
+}
+
+// UserMap is a named map of the users by their names.
+type UserMap map[string]*User
+
+// Row is a named map of the columns of a row.
+type Row map[string]any
+
+// UserRepository covers the result shapes and the statement attributes of the v2 implementation.
+//
+//juice:namespace repo.UserRepository
+type UserRepository interface {
+	// GetByID returns the user of the id, or ErrUserNotFound.
+	GetByID(ctx context.Context, id int64) (*User, error)
+	FindByID(ctx context.Context, id int64) (*User, error)
+	FindByName(ctx context.Context, name string) (user User, found bool, err error)
+	ByIDs(ctx context.Context, ids []int64) (map[int64]User, error)
+	ByNames(ctx context.Context, names []string) (UserMap, error)
+	Row(ctx context.Context, id int64) (map[string]any, error)
+	NamedRow(ctx context.Context, id int64) (Row, error)
+	Names(ctx context.Context) ([]string, error)
+	List(ctx context.Context, sort string) ([]*User, error)
+	CountSince(ctx context.Context, since *time.Time) (int64, error)
+	RenameTo(ctx context.Context, id int64, name *string) error
+	Page(ctx context.Context, limit, offset int) (users []User, total int64, err error)
+	Stream(ctx context.Context) iter.Seq2[User, error]
+	Iter(ctx context.Context) (iter.Seq2[User, error], error)
+	Rows(ctx context.Context) (*sql.Rows, error)
+	Slow(ctx context.Context) ([]User, error)
+	SlowRows(ctx context.Context) (jsql.Rows, error)
+	SlowStream(ctx context.Context) iter.Seq2[User, error]
+	SlowIter(ctx context.Context) (iter.Seq2[User, error], error)
+	Create(ctx context.Context, user *User) (int64, error)
+	CreateAll(ctx context.Context, users []*User) (int64, error)
+	CreateReturning(ctx context.Context, user *User) (User, error)
+	CreateReturningID(ctx context.Context, user *User) (int64, error)
+	Rename(ctx context.Context, user *User) error
+	Delete(ctx context.Context, id int64) (bool, error)
+	DeleteAll(ctx context.Context, ids ...int64) (sql.Result, error)
+	WithTx(tx juice.TxManager) UserRepository
+}
@@ -0,0 +1,442 @@
+// Code generated by "juicecli impl"; DO NOT EDIT.
+
+package repo
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"iter"
+	"time"
+
+	"github.com/go-juicedev/juice"
+	jsql "github.com/go-juicedev/juice/sql"
+)
+
+type UserRepositoryImpl struct {
+	manager juice.Manager
+}
+
+// userRepositoryImplRows cancels the timeout of the query when the rows are closed.
+type userRepositoryImplRows struct {
+	jsql.Rows
+	cancel context.CancelFunc
+}
+
+func (r *userRepositoryImplRows) Close() error {
+	defer r.cancel()
+	return r.Rows.Close()
+}
+
+// GetByID returns the user of the id, or ErrUserNotFound.
+func (u UserRepositoryImpl) GetByID(ctx context.Context, id int64) (result0 *User, result1 error) {
+	ctx = juice.ContextWithManager(ctx, u.manager)
+	ret, err := juice.QueryContext[User](ctx, "repo.UserRepository.GetByID", juice.H{"id": id})
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrUserNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &ret, nil
+}
+
+func (u UserRepositoryImpl) FindByID(ctx context.Context, id int64) (result0 *User, result1 error) {
+	ctx = juice.ContextWithManager(ctx, u.manager)
+	ret, err := juice.QueryContext[User](ctx, "repo.UserRepository.FindByID", juice.H{"id": id})
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &ret, nil
+}
+
+func (u UserRepositoryImpl) FindByName(ctx context.Context, name string) (user User, found bool, err error) {
+	ctx = juice.ContextWithManager(ctx, u.manager)
+	ret, err := juice.QueryContext[User](ctx, "repo.UserRepository.FindByName", juice.H{"name": name})
+	if errors.Is(err, sql.ErrNoRows) {
+		return user, false, nil
+	}
+	if err != nil {
+		return user, false, err
+	}
+	return ret, true, nil
+}
+
+func (u UserRepositoryImpl) ByIDs(ctx context.Context, ids []int64) (result0 map[int64]User, result1 error) {
+	if len(ids) == 0 {
+		return
+	}
+	ctx = juice.ContextWithManager(ctx, u.manager)
+	items, err := juice.QueryListContext[User](ctx, "repo.UserRepository.ByIDs", juice.H{"ids": ids})
+	if err != nil {
+		return nil, err
+	}
+	ret := make(map[int64]User, len(items))
+	for _, item := range items {
+		ret[item.ID] = item
+	}
+	return ret, nil
+}
+
+func (u UserRepositoryImpl) ByNames(ctx context.Context, names []string) (result0 UserMap, result1 error) {
+	if len(names) == 0 {
+		return
+	}
+	ctx = juice.ContextWithManager(ctx, u.manager)
+	items, err := juice.QueryList2Context[User](ctx, "repo.UserRepository.ByNames", juice.H{"names": names})
+	if err != nil {
+		return nil, err
+	}
+	ret := make(UserMap, len(items))
+	for _, item := range items {
+		ret[item.Name] = item
+	}
+	return ret, nil
+}
+
+func (u UserRepositoryImpl) Row(ctx context.Context, id int64) (result0 map[string]any, result1 error) {
+	ctx = juice.ContextWithManager(ctx, u.manager)
+	rows, err := juice.ManagerFromContext(ctx).Object("repo.UserRepository.Row").QueryContext(ctx, juice.H{"id": id})
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = rows.Close() }()
+	if !rows.Next() {
+		if err = rows.Err(); err != nil {
+			return nil, err
+		}
+		return nil, sql.ErrNoRows
+	}
+	columns, err := rows.Columns()
+	if err != nil {
+		return nil, err
+	}
+	values := make([]any, len(columns))
+	dest := make([]any, len(columns))
+	for index := range values {
+		dest[index] = &values[index]
+	}
+	if err = rows.Scan(dest...); err != nil {
+		return nil, err
+	}
+	ret := make(map[string]any, len(columns))
+	for index, column := range columns {
+		ret[column] = values[index]
+	}
+	return ret, nil
+}
+
+func (u UserRepositoryImpl) NamedRow(ctx context.Context, id int64) (result0 Row, result1 error) {
+	ctx = juice.ContextWithManager(ctx, u.manager)
+	rows, err := juice.ManagerFromContext(ctx).Object("repo.UserRepository.NamedRow").QueryContext(ctx, juice.H{"id": id})
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = rows.Close() }()
+	if !rows.Next() {
+		if err = rows.Err(); err != nil {
+			return nil, err
+		}
+		return nil, sql.ErrNoRows
+	}
+	columns, err := rows.Columns()
+	if err != nil {
+		return nil, err
+	}
+	values := make([]any, len(columns))
+	dest := make([]any, len(columns))
+	for index := range values {
+		dest[index] = &values[index]
+	}
+	if err = rows.Scan(dest...); err != nil {
+		return nil, err
+	}
+	ret := make(Row, len(columns))
+	for index, column := range columns {
+		ret[column] = values[index]
+	}
+	return ret, nil
+}
+
+func (u UserRepositoryImpl) Names(ctx context.Context) (result0 []string, result1 error) {
+	ctx = juice.ContextWithManager(ctx, u.manager)
+	return juice.QueryListContext[string](ctx, "repo.UserRepository.Names", nil)
+}
+
+func (u UserRepositoryImpl) List(ctx context.Context, sort string) (result0 []*User, result1 error) {
+	switch sort {
+	case "id", "name":
+	default:
+		result1 = fmt.Errorf("%s: %s %q is not allowed", "List", "sort", sort)
+		return
+	}
+	ctx = juice.ContextWithManager(ctx, u.manager)
+	return juice.QueryList2Context[User](ctx, "repo.UserRepository.List", juice.H{"sort": sort})
+}
+
+func (u UserRepositoryImpl) CountSince(ctx context.Context, since *time.Time) (result0 int64, result1 error) {
+	ctx = juice.ContextWithManager(ctx, u.manager)
+	return juice.QueryContext[int64](ctx, "repo.UserRepository.CountSince", juice.H{"since": since})
+}
+
+func (u UserRepositoryImpl) RenameTo(ctx context.Context, id int64, name *string) (result0 error) {
+	ctx = juice.ContextWithManager(ctx, u.manager)
+	_, err := juice.ExecContext(ctx, "repo.UserRepository.RenameTo", juice.H{"id": id, "name": name})
+	return err
+}
+
+func (u UserRepositoryImpl) Page(ctx context.Context, limit int, offset int) (users []User, total int64, err error) {
+	ctx = juice.ContextWithManager(ctx, u.manager)
+	users, err = juice.QueryListContext[User](ctx, "repo.UserRepository.Page", juice.H{"limit": limit, "offset": offset})
+	if err != nil {
+		return nil, 0, err
+	}
+	total, err = juice.QueryContext[int64](ctx, "repo.UserRepository.Count", juice.H{"limit": limit, "offset": offset})
+	if err != nil {
+		return nil, 0, err
+	}
+	return users, total, nil
+}
+
+func (u UserRepositoryImpl) Stream(ctx context.Context) (result0 iter.Seq2[User, error]) {
+	ctx = juice.ContextWithManager(ctx, u.manager)
+	return func(yield func(User, error) bool) {
+		var zero User
+		rows, err := juice.ManagerFromContext(ctx).Object("repo.UserRepository.Stream").QueryContext(ctx, nil)
+		if err != nil {
+			yield(zero, err)
+			return
+		}
+		defer func() { _ = rows.Close() }()
+		seq, err := juice.Iter[User](rows)
+		if err != nil {
+			yield(zero, err)
+			return
+		}
+		seq(yield)
+	}
+}
+
+func (u UserRepositoryImpl) Iter(ctx context.Context) (result0 iter.Seq2[User, error], result1 error) {
+	ctx = juice.ContextWithManager(ctx, u.manager)
+	rows, err := juice.ManagerFromContext(ctx).Object("repo.UserRepository.Iter").QueryContext(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	seq, err := juice.Iter[User](rows)
+	if err != nil {
+		_ = rows.Close()
+		return nil, err
+	}
+	return func(yield func(User, error) bool) {
+		defer func() { _ = rows.Close() }()
+		seq(yield)
+	}, nil
+}
+
+func (u UserRepositoryImpl) Rows(ctx context.Context) (result0 *sql.Rows, result1 error) {
+	ctx = juice.ContextWithManager(ctx, u.manager)
+	rows, err := juice.ManagerFromContext(ctx).Object("repo.UserRepository.Rows").QueryContext(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	ret, ok := rows.(*sql.Rows)
+	if !ok {
+		_ = rows.Close()
+		return nil, fmt.Errorf("unexpected rows type %T", rows)
+	}
+	return ret, nil
+}
+
+func (u UserRepositoryImpl) Slow(ctx context.Context) (result0 []User, result1 error) {
+	ctx = juice.ContextWithManager(ctx, u.manager)
+	ctx, cancel := context.WithTimeout(ctx, 90*time.Second)
+	defer cancel()
+	return juice.QueryListContext[User](ctx, "repo.UserRepository.Slow", nil)
+}
+
+func (u UserRepositoryImpl) SlowRows(ctx context.Context) (result0 jsql.Rows, result1 error) {
+	ctx = juice.ContextWithManager(ctx, u.manager)
+	ctx, cancel := context.WithTimeout(ctx, 3*time.Second)
+	rows, err := juice.ManagerFromContext(ctx).Object("repo.UserRepository.SlowRows").QueryContext(ctx, nil)
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+	return &userRepositoryImplRows{Rows: rows, cancel: cancel}, nil
+}
+
+func (u UserRepositoryImpl) SlowStream(ctx context.Context) (result0 iter.Seq2[User, error]) {
+	ctx = juice.ContextWithManager(ctx, u.manager)
+	return func(yield func(User, error) bool) {
+		ctx, cancel := context.WithTimeout(ctx, 2*time.Second)
+		defer cancel()
+		var zero User
+		rows, err := juice.ManagerFromContext(ctx).Object("repo.UserRepository.SlowStream").QueryContext(ctx, nil)
+		if err != nil {
+			yield(zero, err)
+			return
+		}
+		defer func() { _ = rows.Close() }()
+		seq, err := juice.Iter[User](rows)
+		if err != nil {
+			yield(zero, err)
+			return
+		}
+		seq(yield)
+	}
+}
+
+func (u UserRepositoryImpl) SlowIter(ctx context.Context) (result0 iter.Seq2[User, error], result1 error) {
+	ctx = juice.ContextWithManager(ctx, u.manager)
+	ctx, cancel := context.WithTimeout(ctx, 2*time.Second)
+	rows, err := juice.ManagerFromContext(ctx).Object("repo.UserRepository.SlowIter").QueryContext(ctx, nil)
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+	seq, err := juice.Iter[User](rows)
+	if err != nil {
+		_ = rows.Close()
+		cancel()
+		return nil, err
+	}
+	return func(yield func(User, error) bool) {
+		defer func() { _ = rows.Close(); cancel() }()
+		seq(yield)
+	}, nil
+}
+
+func (u UserRepositoryImpl) Create(ctx context.Context, user *User) (result0 int64, result1 error) {
+	if user == nil {
+		result1 = fmt.Errorf("%s: %s is nil", "Create", "user")
+		return
+	}
+	ctx = juice.ContextWithManager(ctx, u.manager)
+	result, err := juice.ExecContext(ctx, "repo.UserRepository.Create", user)
+	if err != nil {
+		return 0, err
+	}
+	return result.LastInsertId()
+}
+
+func (u UserRepositoryImpl) CreateAll(ctx context.Context, users []*User) (result0 int64, result1 error) {
+	if len(users) == 0 {
+		return
+	}
+	ctx = juice.ContextWithManager(ctx, u.manager)
+	result, err := juice.ExecContext(ctx, "repo.UserRepository.CreateAll", juice.H{"users": users})
+	if err != nil {
+		return 0, err
+	}
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return 0, err
+	}
+	return affected, nil
+}
+
+func (u UserRepositoryImpl) CreateReturning(ctx context.Context, user *User) (result0 User, result1 error) {
+	if user == nil {
+		result1 = fmt.Errorf("%s: %s is nil", "CreateReturning", "user")
+		return
+	}
+	ctx = juice.ContextWithManager(ctx, u.manager)
+	return juice.QueryContext[User](ctx, "repo.UserRepository.CreateReturning", user)
+}
+
+func (u UserRepositoryImpl) CreateReturningID(ctx context.Context, user *User) (result0 int64, result1 error) {
+	if user == nil {
+		result1 = fmt.Errorf("%s: %s is nil", "CreateReturningID", "user")
+		return
+	}
+	ctx = juice.ContextWithManager(ctx, u.manager)
+	return juice.QueryContext[int64](ctx, "repo.UserRepository.CreateReturningID", user)
+}
+
+func (u UserRepositoryImpl) Rename(ctx context.Context, user *User) (result0 error) {
+	if user == nil {
+		result0 = fmt.Errorf("%s: %s is nil", "Rename", "user")
+		return
+	}
+	ctx = juice.ContextWithManager(ctx, u.manager)
+	result, err := juice.ExecContext(ctx, "repo.UserRepository.Rename", user)
+	if err != nil {
+		return err
+	}
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected != 1 {
+		return fmt.Errorf("%w: %s expected %s rows, got %d", ErrUnexpectedRowCount, "Rename", "1", affected)
+	}
+	return nil
+}
+
+func (u UserRepositoryImpl) Delete(ctx context.Context, id int64) (result0 bool, result1 error) {
+	ctx = juice.ContextWithManager(ctx, u.manager)
+	result, err := juice.ExecContext(ctx, "repo.UserRepository.Delete", juice.H{"id": id})
+	if err != nil {
+		return false, err
+	}
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	return affected > 0, nil
+}
+
+func (u UserRepositoryImpl) DeleteAll(ctx context.Context, ids ...int64) (result0 sql.Result, result1 error) {
+	if len(ids) == 0 {
+		return driver.RowsAffected(0), nil
+	}
+	ctx = juice.ContextWithManager(ctx, u.manager)
+	return juice.ExecContext(ctx, "repo.UserRepository.DeleteAll", juice.H{"ids": ids})
+}
+
+// WithTx returns a copy of the implementation which executes the statements in the transaction of tx.
+func (u UserRepositoryImpl) WithTx(tx juice.TxManager) UserRepository {
+	u.manager = tx
+	return &u
+}
+
+// NewUserRepository returns a new UserRepository.
+func NewUserRepository(manager juice.Manager) UserRepository {
+	return &UserRepositoryImpl{manager: manager}
+}
+
+// RunUserRepositoryInTx calls fn with an implementation of UserRepository which executes the statements in a transaction.
+// If manager is a juice.TxManager, the transaction of it is used and left to its owner.
+// Otherwise manager must be a *juice.Engine, which begins a new transaction,
+// the transaction is committed if fn returns nil and rolled back if fn returns an error or panics.
+func RunUserRepositoryInTx(ctx context.Context, manager juice.Manager, fn func(repo UserRepository) error) (err error) {
+	if tx, ok := manager.(juice.TxManager); ok {
+		return fn(NewUserRepository(tx))
+	}
+	engine, ok := manager.(*juice.Engine)
+	if !ok {
+		return juice.ErrInvalidManager
+	}
+	tx := engine.ContextTx(ctx, nil)
+	if err = tx.Begin(); err != nil {
+		return err
+	}
+	defer func() {
+		if p := recover(); p != nil {
+			_ = tx.Rollback()
+			panic(p)
+		}
+		if err != nil {
+			_ = tx.Rollback()
+			return
+		}
+		err = tx.Commit()
+	}()
+	return fn(NewUserRepository(tx))
+}
@@ -0,0 +1,2 @@
+// Package store holds the implementations of the interfaces of the repo package, generated with --package store.
+package store
@@ -0,0 +1,318 @@
+package internal
+
+import (
+	stdast "go/ast"
+	"go/types"
+	"path"
+	"strconv"
+
+	"github.com/go-juicedev/juicecli/internal/ast"
+)
+
+// The helpers below decide by the resolved types of the values, so that any spelling of a type is recognized,
+// e.g. an aliased import, a named slice type or a type alias.
+// They fall back to the spelling of the types if the types of the package are not loaded.
+
+// isError reports whether the value is of the error type.
+func isError(value *ast.Value) bool {
+	if t := value.Resolved(); t != nil {
+		return types.Identical(t, types.Universe.Lookup("error").Type())
+	}
+	return value.TypeName() == "error"
+}
+
+// isBasic reports whether the value is of the predeclared type of the kind, e.g. int64 or bool.
+func isBasic(value *ast.Value, kind types.BasicKind) bool {
+	if t := value.Resolved(); t != nil {
+		return types.Identical(t, types.Typ[kind])
+	}
+	return value.TypeName() == types.Typ[kind].Name()
+}
+
+// isString reports whether the value is a string, or of a named type of it, e.g. type Sort string.
+func isString(value *ast.Value) bool {
+	if t := value.Resolved(); t != nil {
+		basic, ok := t.Underlying().(*types.Basic)
+		return ok && basic.Info()&types.IsString != 0
+	}
+	return value.TypeName() == "string"
+}
+
+// isPointer reports whether the value is a pointer.
+func isPointer(value *ast.Value) bool {
+	if t := value.Resolved(); t != nil {
+		_, ok := types.Unalias(t).(*types.Pointer)
+		return ok
+	}
+	return value.IsPointerType()
+}
+
+// isScalar reports whether the value is of a predeclared type, or a named type of it, e.g. type Status string,
+// or of a type parameter constrained to them, e.g. ID int64 | string.
+func isScalar(value *ast.Value) bool {
+	if t := value.Resolved(); t != nil {
+		if param, ok := t.(*types.TypeParam); ok {
+			return isScalarConstraint(param.Constraint())
+		}
+		_, ok := t.Underlying().(*types.Basic)
+		return ok
+	}
+	return value.IsBuiltInType()
+}
+
+// isScalarConstraint reports whether the types of the constraint are restricted to the basic types.
+// Constraints without type terms, e.g. any or comparable, are not.
+func isScalarConstraint(constraint types.Type) bool {
+	iface, ok := constraint.Underlying().(*types.Interface)
+	if !ok {
+		return false
+	}
+	var restricted bool
+	for embedded := range iface.EmbeddedTypes() {
+		switch embedded := embedded.(type) {
+		case *types.Union:
+			for term := range embedded.Terms() {
+				if _, ok = term.Type().Underlying().(*types.Basic); !ok {
+					return false
+				}
+			}
+		case *types.Basic:
+		default:
+			if !isScalarConstraint(embedded) {
+				return false
+			}
+		}
+		restricted = true
+	}
+	return restricted
+}
+
+// isColumnType reports whether the type is of a single value of a column, which juice binds as a whole,
+// that is a predeclared type, time.Time, or a type implementing driver.Valuer such as sql.NullString.
+func isColumnType(t types.Type) bool {
+	if _, ok := t.Underlying().(*types.Basic); ok {
+		return true
+	}
+	if isNamedType(t, "time", "Time") {
+		return true
+	}
+	method, _, _ := types.LookupFieldOrMethod(types.NewPointer(t), true, nil, "Value")
+	_, ok := method.(*types.Func)
+	return ok
+}
+
+// isNullable reports whether the value is a pointer to a value of a column, e.g. *int64 or *time.Time,
+// whose nil is bound as NULL.
+func isNullable(value *ast.Value) bool {
+	if t := value.Resolved(); t != nil {
+		pointer, ok := types.Unalias(t).(*types.Pointer)
+		return ok && isColumnType(pointer.Elem())
+	}
+	return value.IsPointerType() && (value.IsBuiltInType() || value.DirectTypename() == "time.Time")
+}
+
+// isRowPointer reports whether the value is a pointer to a struct whose fields are bound by juice, e.g. *User,
+// which is dereferenced unlike a nullable one.
+func isRowPointer(value *ast.Value) bool {
+	if t := value.Resolved(); t != nil {
+		pointer, ok := types.Unalias(t).(*types.Pointer)
+		if !ok {
+			return false
+		}
+		_, ok = pointer.Elem().Underlying().(*types.Struct)
+		return ok && !isColumnType(pointer.Elem())
+	}
+	return value.IsPointerType() && !isNullable(value)
+}
+
+// sliceElem returns the element type of the value if it is a slice of rows or values,
+// including the variadic param. []byte is not, which is a single value of a column.
+func sliceElem(value *ast.Value) (types.Type, bool) {
+	if t := value.Resolved(); t != nil {
+		slice, ok := t.Underlying().(*types.Slice)
+		if !ok || isByte(slice.Elem()) {
+			return nil, false
+		}
+		return slice.Elem(), true
+	}
+	switch value.Field.Type.(type) {
+	case *stdast.ArrayType, *stdast.Ellipsis:
+		return nil, value.TypeName() != "[]byte" && value.TypeName() != "...byte"
+	}
+	return nil, false
+}
+
+// isSlice reports whether the value is a slice of rows or values, see sliceElem.
+func isSlice(value *ast.Value) bool {
+	_, ok := sliceElem(value)
+	return ok
+}
+
+// isByte reports whether the type is byte, or a named type of it.
+func isByte(t types.Type) bool {
+	basic, ok := t.Underlying().(*types.Basic)
+	return ok && basic.Kind() == types.Byte
+}
+
+// isMap reports whether the value is a map, or a named type of it, e.g. type UserMap map[int64]User.
+func isMap(value *ast.Value) bool {
+	if t := value.Resolved(); t != nil {
+		_, ok := t.Underlying().(*types.Map)
+		return ok
+	}
+	_, ok := value.Field.Type.(*stdast.MapType)
+	return ok
+}
+
+// isRowMap reports whether the value is a map of the columns of a row to their values,
+// which is map[string]any, or a named type of it, e.g. type Row map[string]any.
+func isRowMap(value *ast.Value) bool {
+	if t := value.Resolved(); t != nil {
+		m, ok := t.Underlying().(*types.Map)
+		if !ok || !types.Identical(m.Key(), types.Typ[types.String]) {
+			return false
+		}
+		elem, ok := m.Elem().Underlying().(*types.Interface)
+		return ok && elem.Empty()
+	}
+	m, ok := value.Field.Type.(*stdast.MapType)
+	if !ok {
+		return false
+	}
+	key, elem := &ast.Value{Field: &stdast.Field{Type: m.Key}}, &ast.Value{Field: &stdast.Field{Type: m.Value}}
+	return key.TypeName() == "string" && (elem.TypeName() == "any" || elem.TypeName() == "interface{}")
+}
+
+// isNamedType reports whether the type is the named type declared in the package of the import path,
+// or an instance of it if it is generic.
+func isNamedType(t types.Type, importPath, name string) bool {
+	named, ok := types.Unalias(t).(*types.Named)
+	if !ok {
+		return false
+	}
+	object := named.Obj()
+	return object.Pkg() != nil && object.Pkg().Path() == importPath && object.Name() == name
+}
+
+// isNamed reports whether the value is of the named type declared in the package of the import path.
+func (f *Function) isNamed(value *ast.Value, importPath, name string) bool {
+	if t := value.Resolved(); t != nil {
+		return isNamedType(t, importPath, name)
+	}
+	selector, ok := value.Field.Type.(*stdast.SelectorExpr)
+	if !ok || selector.Sel.Name != name {
+		return false
+	}
+	pkg, ok := selector.X.(*stdast.Ident)
+	return ok && f.importPath(pkg.Name) == importPath
+}
+
+// isRawRows reports whether the value is *sql.Rows, or sql.Rows of juice, which are returned to the caller as is.
+func (f *Function) isRawRows(value *ast.Value) bool {
+	if t := value.Resolved(); t != nil {
+		if pointer, ok := types.Unalias(t).(*types.Pointer); ok {
+			return isNamedType(pointer.Elem(), "database/sql", "Rows")
+		}
+		return isNamedType(t, "github.com/go-juicedev/juice/sql", "Rows")
+	}
+	if star, ok := value.Field.Type.(*stdast.StarExpr); ok {
+		return f.isNamed(&ast.Value{Field: &stdast.Field{Type: star.X}}, "database/sql", "Rows")
+	}
+	return f.isNamed(value, "github.com/go-juicedev/juice/sql", "Rows")
+}
+
+// seq2Elem returns the spelling of the element type of the value if it is iter.Seq2[T, error].
+// The element of iter.Seq2 spelled with its type arguments is spelled as is,
+// otherwise, e.g. a type alias of it, it is spelled by its resolved type, whose package is imported if required.
+func (f *Function) seq2Elem(value *ast.Value) (string, bool) {
+	index, spelled := value.Field.Type.(*stdast.IndexListExpr)
+	spelled = spelled && len(index.Indices) == 2
+	if t := value.Resolved(); t != nil {
+		named, ok := types.Unalias(t).(*types.Named)
+		if !ok || !isNamedType(named, "iter", "Seq2") || !types.Identical(named.TypeArgs().At(1), types.Universe.Lookup("error").Type()) {
+			return "", false
+		}
+		if spelled {
+			return (&ast.Value{Field: &stdast.Field{Type: index.Indices[0]}}).TypeName(), true
+		}
+		return types.TypeString(named.TypeArgs().At(0), f.qualifier), true
+	}
+	if !spelled {
+		return "", false
+	}
+	if !f.isNamed(&ast.Value{Field: &stdast.Field{Type: index.X}}, "iter", "Seq2") {
+		return "", false
+	}
+	if errType, ok := index.Indices[1].(*stdast.Ident); !ok || errType.Name != "error" {
+		return "", false
+	}
+	return (&ast.Value{Field: &stdast.Field{Type: index.Indices[0]}}).TypeName(), true
+}
+
+// mapElem returns the spelling of the value type of the value if it is a map.
+// The value type of a map spelled as map[K]T is spelled as T,
+// otherwise, e.g. type UserMap map[int64]User, it is spelled by its resolved type, whose package is imported if required.
+func (f *Function) mapElem(value *ast.Value) (string, bool) {
+	if m, ok := value.Field.Type.(*stdast.MapType); ok {
+		return (&ast.Value{Field: &stdast.Field{Type: m.Value}}).TypeName(), true
+	}
+	if t := value.Resolved(); t != nil {
+		if m, ok := t.Underlying().(*types.Map); ok {
+			return types.TypeString(m.Elem(), f.qualifier), true
+		}
+	}
+	return "", false
+}
+
+// isContext reports whether the value is of context.Context.
+func (f *Function) isContext(value *ast.Value) bool {
+	return f.isNamed(value, "context", "Context")
+}
+
+// isSQLResult reports whether the value is of sql.Result.
+func (f *Function) isSQLResult(value *ast.Value) bool {
+	return f.isNamed(value, "database/sql", "Result")
+}
+
+// listElem returns the spelling of the element type of the value if it is a slice of rows or values.
+// The element of a slice spelled as []T is spelled as T,
+// otherwise, e.g. type Users []User, it is spelled by its resolved type, whose package is imported if required.
+func (f *Function) listElem(value *ast.Value) (string, bool) {
+	elem, ok := sliceElem(value)
+	if !ok {
+		return "", false
+	}
+	switch t := value.Field.Type.(type) {
+	case *stdast.ArrayType:
+		return (&ast.Value{Field: &stdast.Field{Type: t.Elt}}).TypeName(), true
+	case *stdast.Ellipsis:
+		return (&ast.Value{Field: &stdast.Field{Type: t.Elt}}).TypeName(), true
+	}
+	return types.TypeString(elem, f.qualifier), true
+}
+
+// qualifier qualifies the types spelled by their resolved types in the generated code.
+// The types declared by the package of the interface are qualified only if the implementation is generated
+// into another package. The packages are referred to by the names imported by the interface, or imported otherwise.
+func (f *Function) qualifier(pkg *types.Package) string {
+	if f.iface.Types != nil && pkg.Path() == f.iface.Types.Path() {
+		if qualifier := f.iface.Qualifier(); qualifier != "" {
+			f.imports = append(f.imports, f.iface.PackageImport())
+			return qualifier
+		}
+		return ""
+	}
+	for _, spec := range f.iface.File.Imports {
+		imp := &ast.Import{ImportSpec: spec}
+		if imp.UnQuote() == pkg.Path() && imp.Usage() != "_" && imp.Usage() != "." {
+			f.imports = append(f.imports, imp)
+			return imp.Usage()
+		}
+	}
+	spec := &stdast.ImportSpec{Path: &stdast.BasicLit{Value: strconv.Quote(pkg.Path())}}
+	if path.Base(pkg.Path()) != pkg.Name() {
+		spec.Name = stdast.NewIdent(pkg.Name())
+	}
+	f.imports = append(f.imports, &ast.Import{ImportSpec: spec})
+	return pkg.Name()
+}
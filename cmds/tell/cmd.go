@@ -4,8 +4,10 @@ import (
 	"fmt"
 	"github.com/fatih/color"
 	"github.com/go-juicedev/juicecli/internal/command"
+	"github.com/go-juicedev/juicecli/internal/mapper"
 	"github.com/go-juicedev/juicecli/internal/namespace"
 	"github.com/spf13/cobra"
+	"strings"
 )
 
 func do(targetType string) {
@@ -22,16 +24,41 @@ func NewCommand() *cobra.Command {
 	targetType := command.Arg{
 		Name:      "type",
 		ShortHand: "t",
-		Required:  true,
 		Usage:     "The interface type name to generate implementation for (e.g. UserRepository)",
 	}
-	cmd := command.NewCommand("tell", targetType)
+	deprecatedArg := command.Arg{
+		Name:  "deprecated",
+		Usage: "List the deprecated statements and the interface methods in the packages which still reference them",
+		Bool:  true,
+	}
+	configArg := command.Arg{
+		Name:      "config",
+		ShortHand: "c",
+		Usage:     "The configuration file path used with --deprecated. If not specified, it will search for " + strings.Join(mapper.DefaultConfigFiles(), ", ") + " in order",
+	}
+	cmd := command.NewCommand("tell", targetType, deprecatedArg, configArg)
+	cmd.Use = "tell [packages]"
 	cmd.Short = "Auto-generate namespace for an interface type"
-	cmd.Long = "Analyze the interface type and suggest an appropriate namespace based on its name and structure"
+	cmd.Long = "Analyze the interface type and suggest an appropriate namespace based on its name and structure.\n\n" +
+		"With --deprecated, list the statements with the deprecated attribute instead, " +
+		"and the methods of the interfaces in the packages which still reference them. Packages default to ./..."
 	cmd.Example = "  juicecli tell --type UserRepository\n" +
-		"  juicecli tell -t UserRepository"
+		"  juicecli tell -t UserRepository\n" +
+		"  juicecli tell --deprecated ./..."
 	cmd.Run = func(cmd *cobra.Command, args []string) {
 		targetType, _ := cmd.Flags().GetString(targetType.Name)
+		deprecated, _ := cmd.Flags().GetBool(deprecatedArg.Name)
+		config, _ := cmd.Flags().GetString(configArg.Name)
+		if deprecated {
+			if err := listDeprecated(config, args); err != nil {
+				fmt.Println(err)
+			}
+			return
+		}
+		if targetType == "" {
+			fmt.Println(`required flag(s) "type" not set`)
+			return
+		}
 		do(targetType)
 	}
 	return cmd
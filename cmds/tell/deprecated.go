@@ -0,0 +1,124 @@
+package tell
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/fatih/color"
+	astlite "github.com/go-juicedev/juicecli/internal/ast"
+	"github.com/go-juicedev/juicecli/internal/mapper"
+	"github.com/go-juicedev/juicecli/internal/module"
+	"github.com/go-juicedev/juicecli/internal/namespace"
+)
+
+// deprecation is a deprecated statement and the interface methods which still reference it.
+type deprecation struct {
+	statement  *mapper.Statement
+	references []string
+}
+
+// listDeprecated prints the deprecated statements of the mappers declared by the configuration,
+// with the methods of the interfaces in the packages matched by the patterns which reference them.
+func listDeprecated(cfg string, patterns []string) error {
+	deprecations, err := findDeprecations(cfg, patterns)
+	if err != nil {
+		return err
+	}
+	if len(deprecations) == 0 {
+		color.Green("no deprecated statement found")
+		return nil
+	}
+	for _, d := range deprecations {
+		color.Yellow("%s: %s", d.statement.Name(), d.statement.Attribute("deprecated"))
+		switch len(d.references) {
+		case 0:
+			fmt.Println("\tno references")
+		case 1:
+			fmt.Printf("\t1 reference: %s\n", d.references[0])
+		default:
+			fmt.Printf("\t%d references: %s\n", len(d.references), strings.Join(d.references, ", "))
+		}
+	}
+	return nil
+}
+
+// findDeprecations returns the deprecated statements of the mappers declared by the configuration in order,
+// with the methods of the interfaces in the packages matched by the patterns which reference them.
+func findDeprecations(cfg string, patterns []string) ([]*deprecation, error) {
+	filename, err := mapper.FindConfig(cfg)
+	if err != nil {
+		return nil, err
+	}
+	index, err := mapper.Load(filename)
+	if err != nil {
+		return nil, err
+	}
+	var deprecations []*deprecation
+	statements := make(map[string]*deprecation)
+	for _, m := range index.Mappers {
+		for _, statement := range m.Statements {
+			if statement.Attribute("deprecated") == "" {
+				continue
+			}
+			d := &deprecation{statement: statement}
+			deprecations = append(deprecations, d)
+			statements[statement.Name()] = d
+		}
+	}
+	if len(deprecations) == 0 {
+		return nil, nil
+	}
+	if len(patterns) == 0 {
+		patterns = []string{"./..."}
+	}
+	dirs, err := module.ResolvePackageDirs(patterns...)
+	if err != nil {
+		return nil, err
+	}
+	for _, dir := range dirs {
+		if err = findReferences(index, statements, dir); err != nil {
+			return nil, err
+		}
+	}
+	return deprecations, nil
+}
+
+// findReferences adds the methods of the interfaces in the package of dir to the deprecated statements they reference.
+// Like the impl command, only the interfaces whose namespace is declared by a mapper are inspected,
+// and their statements are resolved by namespace.Statement.
+func findReferences(index *mapper.Index, statements map[string]*deprecation, dir string) error {
+	nodes, err := module.FindInterfaceNodes(dir)
+	if err != nil {
+		return err
+	}
+	for _, node := range nodes {
+		iface := &astlite.Interface{InterfaceType: node.Type, TypeParams: node.TypeParams, Name: node.Name, Dir: dir, File: node.File, Doc: node.Doc}
+		ns, err := namespace.OfInterface(iface)
+		if err != nil {
+			return err
+		}
+		if _, ok := index.Mapper(ns); !ok {
+			continue
+		}
+		methods, err := iface.Methods()
+		if err != nil {
+			return err
+		}
+		for _, method := range methods {
+			if _, ok := method.Directive("skip"); ok {
+				continue
+			}
+			name, _, err := namespace.Statement(ns, method, func(name string) bool {
+				_, ok := index.Statement(name)
+				return ok
+			})
+			if err != nil {
+				return fmt.Errorf("%s.%s: %w", node.Name, method.Name(), err)
+			}
+			if d, ok := statements[name]; ok {
+				d.references = append(d.references, node.Name+"."+method.Name())
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,104 @@
+package tell
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestFindDeprecations(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"go.mod": "module example.com/app\n\ngo 1.21\n",
+		"juice.xml": `<configuration>
+    <mappers>
+        <mapper resource="user.xml"/>
+        <mapper resource="base.xml"/>
+    </mappers>
+</configuration>`,
+		"user.xml": `<mapper namespace="repo.UserRepository">
+    <select id="ListUsers" deprecated="use ListActiveUsers">select * from user</select>
+    <select id="ListActiveUsers">select * from user where active</select>
+    <delete id="Purge" deprecated="users are never purged">delete from user</delete>
+    <select id="Count" deprecated="count is cached">select count(*) from user</select>
+</mapper>`,
+		"base.xml": `<mapper namespace="example.com.app.repo.Base">
+    <select id="Ping" deprecated="ping is not needed">select 1</select>
+</mapper>`,
+		"repo/user.go": `package repo
+
+import "context"
+
+// Base is embedded by the repositories, whose statements are under its derived namespace.
+type Base interface {
+	Ping(ctx context.Context) error
+}
+
+//juice:namespace repo.UserRepository
+type UserRepository interface {
+	Base
+	ListUsers(ctx context.Context) ([]string, error)
+	ListActiveUsers(ctx context.Context) ([]string, error)
+	//juice:statement ListUsers
+	All(ctx context.Context) ([]string, error)
+	//juice:skip
+	Purge(ctx context.Context) error
+}
+
+// Unmapped is ignored, since no mapper declares its namespace.
+type Unmapped interface {
+	ListUsers(ctx context.Context) ([]string, error)
+}
+`,
+	}
+	for name, content := range files {
+		if err := os.MkdirAll(filepath.Join(dir, filepath.Dir(name)), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	t.Chdir(dir)
+
+	deprecations, err := findDeprecations("", nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	result := make(map[string][]string)
+	var names []string
+	for _, d := range deprecations {
+		names = append(names, d.statement.Name())
+		result[d.statement.Name()] = d.references
+	}
+	expectedNames := []string{"repo.UserRepository.ListUsers", "repo.UserRepository.Purge", "repo.UserRepository.Count", "example.com.app.repo.Base.Ping"}
+	if !reflect.DeepEqual(names, expectedNames) {
+		t.Fatalf("expected deprecated statements %v, got %v", expectedNames, names)
+	}
+	expected := map[string][]string{
+		"repo.UserRepository.ListUsers":  {"UserRepository.ListUsers", "UserRepository.All"},
+		"repo.UserRepository.Purge":      nil,
+		"repo.UserRepository.Count":      nil,
+		"example.com.app.repo.Base.Ping": {"Base.Ping", "UserRepository.Ping"},
+	}
+	if !reflect.DeepEqual(result, expected) {
+		t.Errorf("expected references %v, got %v", expected, result)
+	}
+}
+
+func TestFindDeprecations_None(t *testing.T) {
+	dir := t.TempDir()
+	config := filepath.Join(dir, "juice.xml")
+	content := `<configuration><mappers><mapper namespace="repo.UserRepository"><select id="Ping">select 1</select></mapper></mappers></configuration>`
+	if err := os.WriteFile(config, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	deprecations, err := findDeprecations(config, []string{dir})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(deprecations) != 0 {
+		t.Errorf("expected no deprecated statements, got %d", len(deprecations))
+	}
+}
@@ -0,0 +1,195 @@
+package ast
+
+import (
+	"fmt"
+	"go/ast"
+	"go/build"
+	"slices"
+	"strconv"
+
+	"github.com/go-juicedev/juicecli/internal/module"
+)
+
+// embeddedMethods returns the methods of the embedded interface.
+// An embedded interface from another package is resolved from its source code,
+// and the types declared by that package are qualified with the package name.
+// The type parameters of an embedded generic interface are replaced with its type arguments.
+func (i *Interface) embeddedMethods(expr ast.Expr) ([]*Function, error) {
+	var typeArgs []ast.Expr
+	switch t := expr.(type) {
+	case *ast.IndexExpr:
+		expr, typeArgs = t.X, []ast.Expr{t.Index}
+	case *ast.IndexListExpr:
+		expr, typeArgs = t.X, t.Indices
+	}
+	var (
+		embedded *Interface
+		pkgName  string
+		spec     *ast.ImportSpec
+		err      error
+	)
+	switch t := expr.(type) {
+	case *ast.Ident:
+		if embedded, err = findInterface(i.Dir, t.Name); err != nil {
+			return nil, err
+		}
+	case *ast.SelectorExpr:
+		ident, ok := t.X.(*ast.Ident)
+		if !ok {
+			return nil, fmt.Errorf("unsupported embedded type %s", t.Sel.Name)
+		}
+		pkgName = ident.Name
+		if spec = findImport(pkgName, i.imports()); spec == nil {
+			return nil, fmt.Errorf("can not find import of %s.%s", pkgName, t.Sel.Name)
+		}
+		path, err := strconv.Unquote(spec.Path.Value)
+		if err != nil {
+			return nil, err
+		}
+		pkg, err := build.Import(path, i.Dir, build.FindOnly)
+		if err != nil {
+			return nil, err
+		}
+		if embedded, err = findInterface(pkg.Dir, t.Sel.Name); err != nil {
+			return nil, err
+		}
+	default:
+		return nil, fmt.Errorf("unsupported embedded type %T", expr)
+	}
+	typeParams := embedded.typeParamNames()
+	if len(typeParams) != len(typeArgs) {
+		return nil, fmt.Errorf("%s: expected %d type arguments, got %d", embedded.Name, len(typeParams), len(typeArgs))
+	}
+	methods, err := embedded.promotedMethods()
+	if err != nil {
+		return nil, err
+	}
+	substitutes := make(map[string]ast.Expr, len(typeParams))
+	for index, name := range typeParams {
+		substitutes[name] = typeArgs[index]
+	}
+	for index, method := range methods {
+		if spec != nil {
+			method = method.qualify(pkgName, spec, typeParams)
+		}
+		methods[index] = method.substitute(substitutes, i.imports())
+	}
+	return methods, nil
+}
+
+// promotedMethods returns the methods of the interface which are promoted to the embedding interface.
+func (i *Interface) promotedMethods() ([]*Function, error) {
+	methods, err := i.Methods()
+	if err != nil {
+		return nil, err
+	}
+	for _, method := range methods {
+		if method.Embedded == nil {
+			method.Embedded = i
+		}
+	}
+	return methods, nil
+}
+
+func findInterface(dir, name string) (*Interface, error) {
+	node, err := module.FindInterfaceNode(dir, name)
+	if err != nil {
+		return nil, err
+	}
+	return &Interface{InterfaceType: node.Type, TypeParams: node.TypeParams, Name: name, Dir: dir, File: node.File, Doc: node.Doc}, nil
+}
+
+// qualify returns a copy of the function whose package local types are qualified with the package name,
+// so that the function can be used outside the package which declares it.
+// The type parameters are left as they are.
+func (f *Function) qualify(pkgName string, spec *ast.ImportSpec, typeParams []string) *Function {
+	field := *f.Field
+	field.Type = rewriteExpr(f.Type, qualifyIdent(pkgName, typeParams))
+	imports := append([]*ast.ImportSpec{spec}, f.imports...)
+	return &Function{Field: &field, Embedded: f.Embedded, imports: imports}
+}
+
+// qualifyIdent returns a rewrite function which qualifies the package local types with the package name.
+func qualifyIdent(pkgName string, typeParams []string) func(ident *ast.Ident) ast.Expr {
+	return func(ident *ast.Ident) ast.Expr {
+		if slices.Contains(typeParams, ident.Name) || isPredeclaredType(ident.Name) {
+			return ident
+		}
+		return &ast.SelectorExpr{X: ast.NewIdent(pkgName), Sel: ident}
+	}
+}
+
+// substitute returns a copy of the function whose type parameters are replaced with the type arguments.
+// The imports are the imports of the file which declares the type arguments.
+func (f *Function) substitute(typeArgs map[string]ast.Expr, imports []*ast.ImportSpec) *Function {
+	if len(typeArgs) == 0 {
+		return f
+	}
+	substitute := func(ident *ast.Ident) ast.Expr {
+		if typeArg, ok := typeArgs[ident.Name]; ok {
+			return typeArg
+		}
+		return ident
+	}
+	field := *f.Field
+	field.Type = rewriteExpr(f.Type, substitute)
+	imports = append(append([]*ast.ImportSpec{}, f.imports...), imports...)
+	return &Function{Field: &field, Embedded: f.Embedded, imports: imports}
+}
+
+// rewriteExpr returns a copy of the type expression whose identifiers are rewritten by the given function.
+func rewriteExpr(expr ast.Expr, rewrite func(ident *ast.Ident) ast.Expr) ast.Expr {
+	switch t := expr.(type) {
+	case *ast.Ident:
+		return rewrite(t)
+	case *ast.StarExpr:
+		return &ast.StarExpr{X: rewriteExpr(t.X, rewrite)}
+	case *ast.ArrayType:
+		return &ast.ArrayType{Len: t.Len, Elt: rewriteExpr(t.Elt, rewrite)}
+	case *ast.MapType:
+		return &ast.MapType{Key: rewriteExpr(t.Key, rewrite), Value: rewriteExpr(t.Value, rewrite)}
+	case *ast.ChanType:
+		return &ast.ChanType{Dir: t.Dir, Value: rewriteExpr(t.Value, rewrite)}
+	case *ast.Ellipsis:
+		return &ast.Ellipsis{Elt: rewriteExpr(t.Elt, rewrite)}
+	case *ast.IndexExpr:
+		return &ast.IndexExpr{X: rewriteExpr(t.X, rewrite), Index: rewriteExpr(t.Index, rewrite)}
+	case *ast.IndexListExpr:
+		indices := make([]ast.Expr, 0, len(t.Indices))
+		for _, index := range t.Indices {
+			indices = append(indices, rewriteExpr(index, rewrite))
+		}
+		return &ast.IndexListExpr{X: rewriteExpr(t.X, rewrite), Indices: indices}
+	case *ast.FuncType:
+		return &ast.FuncType{
+			TypeParams: t.TypeParams,
+			Params:     rewriteFields(t.Params, rewrite),
+			Results:    rewriteFields(t.Results, rewrite),
+		}
+	default:
+		// selector expressions are already qualified
+		return expr
+	}
+}
+
+func rewriteFields(fields *ast.FieldList, rewrite func(ident *ast.Ident) ast.Expr) *ast.FieldList {
+	if fields == nil {
+		return nil
+	}
+	result := &ast.FieldList{List: make([]*ast.Field, 0, len(fields.List))}
+	for _, field := range fields.List {
+		rewritten := *field
+		rewritten.Type = rewriteExpr(field.Type, rewrite)
+		result.List = append(result.List, &rewritten)
+	}
+	return result
+}
+
+func isPredeclaredType(name string) bool {
+	switch name {
+	case "error", "any", "comparable", "byte", "rune", "uintptr":
+		return true
+	default:
+		return isBuiltInType(name)
+	}
+}
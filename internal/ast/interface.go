@@ -3,7 +3,11 @@ package ast
 import (
 	"fmt"
 	"go/ast"
+	"go/token"
+	"go/types"
 	"log"
+	"path"
+	"strconv"
 	"strings"
 )
 
@@ -12,6 +16,31 @@ const (
 	ResultPrefix = "result"
 )
 
+// directivePrefix is the prefix of the juice directives in the comments of a method or an interface, e.g. //juice:skip
+const directivePrefix = "//juice:"
+
+// directives returns the arguments of the juice directives with the given name declared in the comment groups.
+func directives(name string, groups ...*ast.CommentGroup) []string {
+	var result []string
+	for _, group := range groups {
+		if group == nil {
+			continue
+		}
+		for _, comment := range group.List {
+			args, ok := strings.CutPrefix(comment.Text, directivePrefix+name)
+			if !ok {
+				continue
+			}
+			if args == "" {
+				result = append(result, "")
+			} else if args[0] == ' ' || args[0] == '\t' {
+				result = append(result, strings.TrimSpace(args))
+			}
+		}
+	}
+	return result
+}
+
 func isBuiltInType(name string) bool {
 	switch name {
 	case "int", "int8", "int16", "int32", "int64":
@@ -35,6 +64,13 @@ func isBuiltInType(name string) bool {
 type Value struct {
 	*ast.Field
 	name string
+	// typ is the resolved type of the value, nil if the types of the package are not loaded.
+	typ types.Type
+}
+
+// Resolved returns the resolved type of the value, nil if the types of the package are not loaded.
+func (v *Value) Resolved() types.Type {
+	return v.typ
 }
 
 // TypeName returns the type name of value.
@@ -52,9 +88,6 @@ func (v *Value) TypeName() string {
 		return "[]" + value.TypeName()
 	case *ast.MapType:
 		key := &Value{Field: &ast.Field{Type: t.Key}}
-		if key.TypeName() != "string" {
-			log.Fatalf("map key must be string, but got %s", key.TypeName())
-		}
 		value := &Value{Field: &ast.Field{Type: t.Value}}
 		return "map[" + key.TypeName() + "]" + value.TypeName()
 	case *ast.SelectorExpr:
@@ -76,6 +109,12 @@ func (v *Value) TypeName() string {
 			indices = append(indices, indexValue.TypeName())
 		}
 		return baseValue.TypeName() + "[" + strings.Join(indices, ", ") + "]"
+	case *ast.Ellipsis:
+		// variadic parameter, e.g. ids ...int64
+		value := &Value{Field: &ast.Field{Type: t.Elt}}
+		return "..." + value.TypeName()
+	case *ast.InterfaceType:
+		return types.ExprString(t)
 	default:
 		log.Fatal("unknown type")
 		return ""
@@ -116,14 +155,23 @@ func (v *Value) DirectTypename() string {
 // ValueGroup is a group of Value. It is used to represent the return values of a method.
 type ValueGroup []*Value
 
+// Imports returns the imports used by the types of the values,
+// including the ones used by the type arguments, e.g. iter.Seq2[model.User, error].
 func (vs ValueGroup) Imports(pkgImports []*ast.ImportSpec) ImportGroup {
 	var result ImportGroup
 	for _, v := range vs {
-		if name := v.ImportPackageName(); name != "" {
-			if imp := findImport(name, pkgImports); imp != nil {
-				result = append(result, &Import{ImportSpec: imp})
+		ast.Inspect(v.Type, func(node ast.Node) bool {
+			selector, ok := node.(*ast.SelectorExpr)
+			if !ok {
+				return true
 			}
-		}
+			if ident, ok := selector.X.(*ast.Ident); ok {
+				if imp := findImport(ident.Name, pkgImports); imp != nil {
+					result = append(result, &Import{ImportSpec: imp})
+				}
+			}
+			return false
+		})
 	}
 	return result
 }
@@ -150,6 +198,17 @@ func (vs ValueGroup) NameAt(prefix string, index int) string {
 	return name
 }
 
+// resolve sets the resolved types of the values from the tuple of the signature.
+// It does nothing if the tuple does not match the values, e.g. the signature of another method.
+func (vs ValueGroup) resolve(tuple *types.Tuple) {
+	if tuple.Len() != len(vs) {
+		return
+	}
+	for index, v := range vs {
+		v.typ = tuple.At(index).Type()
+	}
+}
+
 // valueGroupFrom returns a ValueGroup from fields.
 func valueGroupFrom(fields []*ast.Field) ValueGroup {
 	var result = make(ValueGroup, 0, len(fields))
@@ -165,28 +224,215 @@ func valueGroupFrom(fields []*ast.Field) ValueGroup {
 	return result
 }
 
-type Interface struct{ *ast.InterfaceType }
+type Interface struct {
+	*ast.InterfaceType
+	// TypeParams are the type parameters of a generic interface, nil if the interface is not generic.
+	TypeParams *ast.FieldList
+	// Name is the type name of the interface.
+	Name string
+	// Dir is the directory of the package which declares the interface.
+	// It is used to resolve the embedded interfaces.
+	Dir string
+	// File is the file which declares the interface.
+	File *ast.File
+	// qualifier is the package name used to refer to the interface outside its package, see Qualify.
+	qualifier string
+	// spec is the import of the package which declares the interface.
+	spec *ast.ImportSpec
+	// Doc is the doc comment of the interface type, which declares the juice directives of the interface.
+	Doc *ast.CommentGroup
+	// Types is the type-checked package which declares the interface, nil if not loaded.
+	// It resolves the types of the params and results of the methods, see Value.Resolved.
+	Types *types.Package
+}
+
+// Qualifier returns the package name which qualifies the types declared by the package of the interface,
+// empty if the interface is not qualified, see Qualify.
+func (i *Interface) Qualifier() string {
+	return i.qualifier
+}
+
+// Directive returns the arguments of the juice directive with the given name declared in the doc comment of the interface.
+func (i *Interface) Directive(name string) (string, bool) {
+	if args := directives(name, i.Doc); len(args) > 0 {
+		return args[0], true
+	}
+	return "", false
+}
+
+// Qualify returns a copy of the interface which is referred to outside the package which declares it.
+// The package local types used by the methods and type parameters are qualified with the package name,
+// and the import of the package is added to their imports.
+func (i *Interface) Qualify(pkgName, importPath string) *Interface {
+	spec := &ast.ImportSpec{Path: &ast.BasicLit{Kind: token.STRING, Value: strconv.Quote(importPath)}}
+	if path.Base(importPath) != pkgName {
+		spec.Name = ast.NewIdent(pkgName)
+	}
+	qualified := *i
+	qualified.qualifier, qualified.spec = pkgName, spec
+	if i.TypeParams != nil {
+		qualified.TypeParams = rewriteFields(i.TypeParams, qualifyIdent(pkgName, i.typeParamNames()))
+	}
+	return &qualified
+}
+
+// PackageImport returns the import of the package which declares the interface, see Qualify.
+// It returns nil if the interface is not qualified.
+func (i *Interface) PackageImport() *Import {
+	if i.spec == nil {
+		return nil
+	}
+	return &Import{ImportSpec: i.spec}
+}
+
+// QualifiedName returns the name used to refer to the interface, see Qualify.
+func (i *Interface) QualifiedName() string {
+	if i.qualifier == "" {
+		return i.Name
+	}
+	return i.qualifier + "." + i.Name
+}
 
 // Methods returns all methods of interface.
-func (i *Interface) Methods() []*Function {
+// The methods of embedded interfaces are resolved recursively.
+func (i *Interface) Methods() ([]*Function, error) {
 	var result = make([]*Function, 0, len(i.InterfaceType.Methods.List))
-	for _, method := range i.InterfaceType.Methods.List {
-		result = append(result, &Function{Field: method})
+	var names = make(map[string]struct{})
+	add := func(method *Function) {
+		// the same method may be embedded more than once
+		if _, ok := names[method.Name()]; ok {
+			return
+		}
+		names[method.Name()] = struct{}{}
+		result = append(result, method)
 	}
-	return result
+	for _, field := range i.InterfaceType.Methods.List {
+		if _, ok := field.Type.(*ast.FuncType); ok {
+			add(&Function{Field: field, imports: i.imports()})
+			continue
+		}
+		methods, err := i.embeddedMethods(field.Type)
+		if err != nil {
+			return nil, err
+		}
+		for _, method := range methods {
+			add(method)
+		}
+	}
+	if i.qualifier != "" {
+		for index, method := range result {
+			result[index] = method.qualify(i.qualifier, i.spec, i.typeParamNames())
+		}
+	}
+	i.resolve(result)
+	return result, nil
+}
+
+// resolve sets the resolved signatures of the methods from the type-checked package of the interface.
+// The methods of embedded interfaces are resolved as well, with the type arguments substituted.
+func (i *Interface) resolve(methods []*Function) {
+	if i.Types == nil {
+		return
+	}
+	object := i.Types.Scope().Lookup(i.Name)
+	if object == nil {
+		return
+	}
+	iface, ok := object.Type().Underlying().(*types.Interface)
+	if !ok {
+		return
+	}
+	signatures := make(map[string]*types.Signature, iface.NumMethods())
+	for index := range iface.NumMethods() {
+		method := iface.Method(index)
+		signatures[method.Name()] = method.Signature()
+	}
+	for _, method := range methods {
+		method.signature = signatures[method.Name()]
+	}
+}
+
+// TypeParamDecl returns the type parameter declaration of the interface.
+// For example, [K comparable, V any]. It returns an empty string if the interface is not generic.
+func (i *Interface) TypeParamDecl() string {
+	if i.TypeParams == nil || len(i.TypeParams.List) == 0 {
+		return ""
+	}
+	var params []string
+	for _, field := range i.TypeParams.List {
+		var names []string
+		for _, name := range field.Names {
+			names = append(names, name.Name)
+		}
+		params = append(params, strings.Join(names, ", ")+" "+types.ExprString(field.Type))
+	}
+	return "[" + strings.Join(params, ", ") + "]"
+}
+
+// TypeArgs returns the type parameters of the interface as type arguments.
+// For example, [K, V]. It returns an empty string if the interface is not generic.
+func (i *Interface) TypeArgs() string {
+	if names := i.typeParamNames(); len(names) > 0 {
+		return "[" + strings.Join(names, ", ") + "]"
+	}
+	return ""
+}
+
+// TypeParamImports returns the imports required by the constraints of the type parameters.
+func (i *Interface) TypeParamImports(pkgImports []*ast.ImportSpec) ImportGroup {
+	if i.TypeParams == nil {
+		return nil
+	}
+	if i.spec != nil {
+		pkgImports = append([]*ast.ImportSpec{i.spec}, pkgImports...)
+	}
+	return valueGroupFrom(i.TypeParams.List).Imports(pkgImports)
+}
+
+func (i *Interface) typeParamNames() []string {
+	if i.TypeParams == nil {
+		return nil
+	}
+	var names []string
+	for _, field := range i.TypeParams.List {
+		for _, name := range field.Names {
+			names = append(names, name.Name)
+		}
+	}
+	return names
+}
+
+func (i *Interface) imports() []*ast.ImportSpec {
+	if i.File == nil {
+		return nil
+	}
+	return i.File.Imports
 }
 
 // Imports returns all imports of interface.
-func (i *Interface) Imports(pkgImports []*ast.ImportSpec) ImportGroup {
+func (i *Interface) Imports(pkgImports []*ast.ImportSpec) (ImportGroup, error) {
+	methods, err := i.Methods()
+	if err != nil {
+		return nil, err
+	}
 	var result ImportGroup
-	for _, method := range i.Methods() {
+	for _, method := range methods {
 		result = append(result, method.Imports(pkgImports)...)
 	}
-	return result.Uniq()
+	return result.Uniq(), nil
 }
 
 // Function wraps ast.Field to provide some useful methods.
-type Function struct{ *ast.Field }
+type Function struct {
+	*ast.Field
+	// Embedded is the embedded interface which declares the function.
+	// It is nil if the function is declared by the interface itself.
+	Embedded *Interface
+	// imports are the imports of the file which declares the function.
+	imports []*ast.ImportSpec
+	// signature is the resolved signature of the function, nil if the types of the package are not loaded.
+	signature *types.Signature
+}
 
 // Name returns the name of the function.
 func (f *Function) Name() string {
@@ -196,16 +442,83 @@ func (f *Function) Name() string {
 	return f.Names[0].Name
 }
 
+// Comment returns the doc comment of the function, one comment per line.
+// The juice directives are excluded, as well as the empty lines which separate them from the doc.
 func (f *Function) Comment() string {
 	if f.Doc == nil {
 		return ""
 	}
-	var builder strings.Builder
+	var lines []string
 	for _, comment := range f.Doc.List {
-		builder.WriteString(comment.Text)
-		builder.WriteString("\n")
+		if strings.HasPrefix(comment.Text, directivePrefix) {
+			continue
+		}
+		lines = append(lines, comment.Text)
 	}
-	return builder.String()
+	for len(lines) > 0 && strings.TrimSpace(lines[len(lines)-1]) == "//" {
+		lines = lines[:len(lines)-1]
+	}
+	if len(lines) == 0 {
+		return ""
+	}
+	return strings.Join(lines, "\n") + "\n"
+}
+
+// Directive returns the arguments of the juice directive with the given name,
+// which is declared in the doc comment or the line comment of the function.
+func (f *Function) Directive(name string) (string, bool) {
+	if args := directives(name, f.Doc, f.Field.Comment); len(args) > 0 {
+		return args[0], true
+	}
+	return "", false
+}
+
+// ParamNames returns the names of the unnamed params declared by the //juice:param directives,
+// keyed by the index of the params, e.g. //juice:param 1=userID 2=name.
+func (f *Function) ParamNames() (map[int]string, error) {
+	args := directives("param", f.Doc, f.Field.Comment)
+	if len(args) == 0 {
+		return nil, nil
+	}
+	params := f.declaredParams()
+	names := make(map[int]string)
+	for _, arg := range args {
+		for _, pair := range strings.Fields(arg) {
+			key, name, ok := strings.Cut(pair, "=")
+			if !ok {
+				return nil, fmt.Errorf("invalid param directive %q, expected index=name", pair)
+			}
+			index, err := strconv.Atoi(key)
+			if err != nil || index < 0 || index >= len(params) {
+				return nil, fmt.Errorf("invalid param directive %q, index out of range", pair)
+			}
+			if !token.IsIdentifier(name) {
+				return nil, fmt.Errorf("invalid param directive %q, %s is not an identifier", pair, name)
+			}
+			if params[index].Name() != "" {
+				return nil, fmt.Errorf("invalid param directive %q, param %d is named %s", pair, index, params[index].Name())
+			}
+			if _, ok := names[index]; ok {
+				return nil, fmt.Errorf("invalid param directive %q, param %d is named more than once", pair, index)
+			}
+			names[index] = name
+		}
+	}
+	seen := make(map[string]bool, len(names))
+	for index, param := range params {
+		name := param.Name()
+		if name == "" {
+			name = names[index]
+		}
+		if name == "" || name == "_" {
+			continue
+		}
+		if seen[name] {
+			return nil, fmt.Errorf("invalid param directive, duplicate param name %s", name)
+		}
+		seen[name] = true
+	}
+	return names, nil
 }
 
 // Signature returns the signature of function.
@@ -224,25 +537,51 @@ func (f *Function) Signature() string {
 }
 
 // Params returns all params of function.
+// The unnamed params are named by the //juice:param directives if they are valid, see ParamNames.
 func (f *Function) Params() ValueGroup {
+	params := f.declaredParams()
+	names, err := f.ParamNames()
+	if err != nil || len(names) == 0 {
+		return params
+	}
+	for index, name := range names {
+		params[index] = &Value{Field: params[index].Field, name: name, typ: params[index].typ}
+	}
+	return params
+}
+
+// declaredParams returns the params of function as declared.
+func (f *Function) declaredParams() ValueGroup {
 	method, ok := f.Type.(*ast.FuncType)
 	if !ok {
 		return nil
 	}
-	return valueGroupFrom(method.Params.List)
+	params := valueGroupFrom(method.Params.List)
+	if f.signature != nil {
+		params.resolve(f.signature.Params())
+	}
+	return params
 }
 
 // Results returns all results of function.
 func (f *Function) Results() ValueGroup {
 	method, ok := f.Type.(*ast.FuncType)
-	if !ok {
+	if !ok || method.Results == nil {
 		return nil
 	}
-	return valueGroupFrom(method.Results.List)
+	results := valueGroupFrom(method.Results.List)
+	if f.signature != nil {
+		results.resolve(f.signature.Results())
+	}
+	return results
 }
 
 // Imports returns all imports of function.
+// If the function is declared in another file, the imports of that file are used instead of pkgImports.
 func (f *Function) Imports(pkgImports []*ast.ImportSpec) ImportGroup {
+	if f.imports != nil {
+		pkgImports = f.imports
+	}
 	return append(f.Params().Imports(pkgImports), f.Results().Imports(pkgImports)...).Uniq()
 }
 
@@ -4,6 +4,9 @@ import (
 	"go/ast"
 	"go/parser"
 	"go/token"
+	"os"
+	"path/filepath"
+	"strings"
 	"testing"
 )
 
@@ -37,8 +40,12 @@ type User struct{}
 			if !ok {
 				return true
 			}
-			iface := &Interface{kind}
-			for _, m := range iface.Methods() {
+			iface := &Interface{InterfaceType: kind}
+			methods, err := iface.Methods()
+			if err != nil {
+				t.Fatal(err)
+			}
+			for _, m := range methods {
 				t.Log(m.Signature())
 				t.Log(m.Imports(f.Imports))
 			}
@@ -46,3 +53,295 @@ type User struct{}
 		return true
 	})
 }
+
+func TestInterfaceEmbedded(t *testing.T) {
+	dir := t.TempDir()
+	var base = `
+package repo
+
+import "context"
+
+type Entity struct{}
+
+type BaseRepo interface {
+	Get(ctx context.Context, id int64) (*Entity, error)
+}
+`
+	if err := os.WriteFile(filepath.Join(dir, "base.go"), []byte(base), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	var src = `
+package repo
+
+import "io"
+
+type UserRepo interface {
+	BaseRepo
+	io.Closer
+	Count() (int64, error)
+}
+`
+	fset := token.NewFileSet()
+	f, err := parser.ParseFile(fset, "", src, parser.ParseComments)
+	if err != nil {
+		t.Fatal(err)
+	}
+	spec := f.Decls[1].(*ast.GenDecl).Specs[0].(*ast.TypeSpec)
+	iface := &Interface{InterfaceType: spec.Type.(*ast.InterfaceType), Name: spec.Name.Name, Dir: dir, File: f}
+	methods, err := iface.Methods()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var expected = []struct {
+		signature string
+		embedded  string
+		imports   string
+	}{
+		{"Get(ctx context.Context, id int64) (result0 *Entity, result1 error)", "BaseRepo", `import "context"`},
+		{"Close() (result0 error)", "Closer", ""},
+		{"Count() (result0 int64, result1 error)", "", ""},
+	}
+	if len(methods) != len(expected) {
+		t.Fatalf("expected %d methods, got %d", len(expected), len(methods))
+	}
+	for index, method := range methods {
+		if signature := method.Signature(); signature != expected[index].signature {
+			t.Errorf("expected signature %q, got %q", expected[index].signature, signature)
+		}
+		var embedded string
+		if method.Embedded != nil {
+			embedded = method.Embedded.Name
+		}
+		if embedded != expected[index].embedded {
+			t.Errorf("%s: expected embedded %q, got %q", method.Name(), expected[index].embedded, embedded)
+		}
+		if imports := method.Imports(f.Imports).String(); imports != expected[index].imports {
+			t.Errorf("%s: expected imports %q, got %q", method.Name(), expected[index].imports, imports)
+		}
+	}
+}
+
+func TestInterfaceGeneric(t *testing.T) {
+	dir := t.TempDir()
+	var base = `
+package repo
+
+import "context"
+
+type CRUD[T any, ID comparable] interface {
+	Get(ctx context.Context, id ID) (*T, error)
+}
+`
+	if err := os.WriteFile(filepath.Join(dir, "base.go"), []byte(base), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	var src = `
+package repo
+
+type Repo[T any, ID comparable] interface {
+	List() ([]T, error)
+}
+
+type UserRepo interface {
+	CRUD[User, int64]
+}
+`
+	fset := token.NewFileSet()
+	f, err := parser.ParseFile(fset, "", src, parser.ParseComments)
+	if err != nil {
+		t.Fatal(err)
+	}
+	generic := f.Decls[0].(*ast.GenDecl).Specs[0].(*ast.TypeSpec)
+	iface := &Interface{InterfaceType: generic.Type.(*ast.InterfaceType), TypeParams: generic.TypeParams, Name: generic.Name.Name, Dir: dir, File: f}
+	if decl := iface.TypeParamDecl(); decl != "[T any, ID comparable]" {
+		t.Errorf("unexpected type param decl %q", decl)
+	}
+	if args := iface.TypeArgs(); args != "[T, ID]" {
+		t.Errorf("unexpected type args %q", args)
+	}
+
+	embedding := f.Decls[1].(*ast.GenDecl).Specs[0].(*ast.TypeSpec)
+	iface = &Interface{InterfaceType: embedding.Type.(*ast.InterfaceType), Name: embedding.Name.Name, Dir: dir, File: f}
+	if decl := iface.TypeParamDecl(); decl != "" {
+		t.Errorf("unexpected type param decl %q", decl)
+	}
+	methods, err := iface.Methods()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(methods) != 1 {
+		t.Fatalf("expected 1 method, got %d", len(methods))
+	}
+	if signature := methods[0].Signature(); signature != "Get(ctx context.Context, id int64) (result0 *User, result1 error)" {
+		t.Errorf("unexpected signature %q", signature)
+	}
+}
+
+func TestFunctionDirective(t *testing.T) {
+	var src = `
+package repo
+
+type Repo interface {
+	// Close closes the repository.
+	//juice:skip
+	Close() error
+	//juice:statement FindByID
+	Get(id int64) error
+	List() error //juice:skip
+	//juice:skipped
+	Count() error
+}
+`
+	f, err := parser.ParseFile(token.NewFileSet(), "", src, parser.ParseComments)
+	if err != nil {
+		t.Fatal(err)
+	}
+	spec := f.Decls[0].(*ast.GenDecl).Specs[0].(*ast.TypeSpec)
+	iface := &Interface{InterfaceType: spec.Type.(*ast.InterfaceType)}
+	methods, err := iface.Methods()
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := map[string]bool{"Close": true, "Get": false, "List": true, "Count": false}
+	for _, method := range methods {
+		if _, ok := method.Directive("skip"); ok != expected[method.Name()] {
+			t.Errorf("%s: expected skip directive %v, got %v", method.Name(), expected[method.Name()], ok)
+		}
+	}
+	if args, ok := methods[1].Directive("statement"); !ok || args != "FindByID" {
+		t.Errorf("expected statement directive FindByID, got %q", args)
+	}
+}
+
+func TestValueTypeName(t *testing.T) {
+	var src = `
+package repo
+
+import (
+	"iter"
+
+	"example.com/app/model"
+)
+
+type Repo interface {
+	ByID() (map[int64]model.User, error)
+	Row() (map[string]interface{}, error)
+	Stream() iter.Seq2[model.User, error]
+	Delete(ids ...int64) error
+}
+`
+	f, err := parser.ParseFile(token.NewFileSet(), "", src, parser.ParseComments)
+	if err != nil {
+		t.Fatal(err)
+	}
+	spec := f.Decls[1].(*ast.GenDecl).Specs[0].(*ast.TypeSpec)
+	iface := &Interface{InterfaceType: spec.Type.(*ast.InterfaceType)}
+	methods, err := iface.Methods()
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []string{"map[int64]model.User", "map[string]interface{}", "iter.Seq2[model.User, error]"}
+	for index, method := range methods[:len(expected)] {
+		if typename := method.Results()[0].TypeName(); typename != expected[index] {
+			t.Errorf("expected %s, got %s", expected[index], typename)
+		}
+	}
+	if typename := methods[3].Params()[0].TypeName(); typename != "...int64" {
+		t.Errorf("expected ...int64, got %s", typename)
+	}
+	imports := methods[2].Imports(f.Imports)
+	if len(imports) != 2 || imports[0].UnQuote() != "iter" || imports[1].UnQuote() != "example.com/app/model" {
+		t.Errorf("expected imports of iter and model, got %v", imports)
+	}
+}
+
+func TestFunctionParamNames(t *testing.T) {
+	var src = `
+package repo
+
+import "context"
+
+type Repo interface {
+	//juice:param 1=name 2=limit
+	Search(context.Context, string, int) error
+	//juice:param 1=name
+	Get(ctx context.Context, id int64) error
+	//juice:param 3=name
+	Count(context.Context, string) error
+	//juice:param 1=limit
+	//juice:param 2=limit
+	List(context.Context, int, int) error
+}
+`
+	f, err := parser.ParseFile(token.NewFileSet(), "", src, parser.ParseComments)
+	if err != nil {
+		t.Fatal(err)
+	}
+	spec := f.Decls[1].(*ast.GenDecl).Specs[0].(*ast.TypeSpec)
+	iface := &Interface{InterfaceType: spec.Type.(*ast.InterfaceType)}
+	methods, err := iface.Methods()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if signature := methods[0].Signature(); signature != "Search(arg0 context.Context, name string, limit int) (result0 error)" {
+		t.Errorf("unexpected signature %q", signature)
+	}
+	for _, method := range methods[1:] {
+		if _, err := method.ParamNames(); err == nil {
+			t.Errorf("%s: expected invalid param directive", method.Name())
+		}
+		if signature := method.Signature(); strings.Contains(signature, "limit") || strings.Contains(signature, "name") {
+			t.Errorf("%s: expected params not renamed, got %q", method.Name(), signature)
+		}
+	}
+}
+
+func TestInterfaceDirective(t *testing.T) {
+	var src = `
+package repo
+
+// Repo is a repository.
+//
+//juice:namespace app.users
+type Repo interface{}
+`
+	f, err := parser.ParseFile(token.NewFileSet(), "", src, parser.ParseComments)
+	if err != nil {
+		t.Fatal(err)
+	}
+	iface := &Interface{Doc: f.Decls[0].(*ast.GenDecl).Doc}
+	if ns, ok := iface.Directive("namespace"); !ok || ns != "app.users" {
+		t.Errorf("expected namespace directive app.users, got %q", ns)
+	}
+}
+
+func TestFunctionComment(t *testing.T) {
+	var src = `
+package repo
+
+type Repo interface {
+	// Get returns the user.
+	//
+	//juice:statement GetByID
+	Get(id int64) error
+	//juice:skip
+	Close() error
+}
+`
+	f, err := parser.ParseFile(token.NewFileSet(), "", src, parser.ParseComments)
+	if err != nil {
+		t.Fatal(err)
+	}
+	spec := f.Decls[0].(*ast.GenDecl).Specs[0].(*ast.TypeSpec)
+	iface := &Interface{InterfaceType: spec.Type.(*ast.InterfaceType)}
+	methods, err := iface.Methods()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if comment := methods[0].Comment(); comment != "// Get returns the user.\n" {
+		t.Errorf("unexpected comment %q", comment)
+	}
+	if comment := methods[1].Comment(); comment != "" {
+		t.Errorf("expected empty comment, got %q", comment)
+	}
+}
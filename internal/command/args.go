@@ -6,4 +6,6 @@ type Arg struct {
 	Value     string
 	Usage     string
 	Required  bool
+	// Bool makes the arg a boolean flag, the Value "true" makes it enabled by default.
+	Bool bool
 }
@@ -5,7 +5,11 @@ import "github.com/spf13/cobra"
 func NewCommand(name string, args ...Arg) *cobra.Command {
 	var cmd = &cobra.Command{Use: name}
 	for _, arg := range args {
-		cmd.Flags().StringP(arg.Name, arg.ShortHand, arg.Value, arg.Usage)
+		if arg.Bool {
+			cmd.Flags().BoolP(arg.Name, arg.ShortHand, arg.Value == "true", arg.Usage)
+		} else {
+			cmd.Flags().StringP(arg.Name, arg.ShortHand, arg.Value, arg.Usage)
+		}
 		if arg.Required {
 			_ = cmd.MarkFlagRequired(arg.Name)
 		}
@@ -0,0 +1,215 @@
+package diff
+
+import (
+	"fmt"
+	"strings"
+)
+
+// contextLines is the number of unchanged lines shown around each change.
+const contextLines = 3
+
+type opKind byte
+
+const (
+	opEqual  opKind = ' '
+	opDelete opKind = '-'
+	opInsert opKind = '+'
+)
+
+type op struct {
+	kind opKind
+	text string
+}
+
+// Unified returns the unified diff of the two texts, or an empty string if they are equal.
+func Unified(oldName, newName, oldText, newText string) string {
+	if oldText == newText {
+		return ""
+	}
+	ops := lines(splitLines(oldText), splitLines(newText))
+	var builder strings.Builder
+	builder.WriteString(fmt.Sprintf("--- %s\n+++ %s\n", oldName, newName))
+	for _, h := range hunks(ops) {
+		builder.WriteString(h)
+	}
+	return builder.String()
+}
+
+func splitLines(text string) []string {
+	if text == "" {
+		return nil
+	}
+	result := strings.SplitAfter(text, "\n")
+	if result[len(result)-1] == "" {
+		result = result[:len(result)-1]
+	}
+	return result
+}
+
+// lines returns the shortest edit script which turns a into b,
+// using the linear space variant of the Myers' algorithm.
+func lines(a, b []string) []op {
+	d := &differ{a: a, b: b}
+	d.compare(0, len(a), 0, len(b))
+	return d.ops
+}
+
+// differ builds the edit script which turns a into b.
+type differ struct {
+	a, b []string
+	ops  []op
+}
+
+// compare appends the edit script which turns a[aLo:aHi] into b[bLo:bHi].
+// The common prefix and suffix are equal lines, an empty side is inserted or deleted as a whole,
+// and the rest is split by its middle snake into two halves compared recursively.
+func (d *differ) compare(aLo, aHi, bLo, bHi int) {
+	for aLo < aHi && bLo < bHi && d.a[aLo] == d.b[bLo] {
+		d.ops = append(d.ops, op{kind: opEqual, text: d.a[aLo]})
+		aLo, bLo = aLo+1, bLo+1
+	}
+	aEnd, bEnd := aHi, bHi
+	for aLo < aEnd && bLo < bEnd && d.a[aEnd-1] == d.b[bEnd-1] {
+		aEnd, bEnd = aEnd-1, bEnd-1
+	}
+	switch {
+	case aLo == aEnd:
+		for _, text := range d.b[bLo:bEnd] {
+			d.ops = append(d.ops, op{kind: opInsert, text: text})
+		}
+	case bLo == bEnd:
+		for _, text := range d.a[aLo:aEnd] {
+			d.ops = append(d.ops, op{kind: opDelete, text: text})
+		}
+	default:
+		// both sides differ at their ends, so the script has at least two edits,
+		// and each half has fewer edits than the whole
+		x, y, u, v := d.middleSnake(aLo, aEnd, bLo, bEnd)
+		d.compare(aLo, x, bLo, y)
+		for ; x < u; x++ {
+			d.ops = append(d.ops, op{kind: opEqual, text: d.a[x]})
+		}
+		d.compare(u, aEnd, v, bEnd)
+	}
+	for _, text := range d.a[aEnd:aHi] {
+		d.ops = append(d.ops, op{kind: opEqual, text: text})
+	}
+}
+
+// middleSnake returns the snake from (x, y) to (u, v) in the middle of the shortest edit script
+// which turns a[aLo:aHi] into b[bLo:bHi], found by searching forward from the start and backward from the end
+// until the paths overlap. Only the furthest reaching paths of the current edit count are kept.
+func (d *differ) middleSnake(aLo, aHi, bLo, bHi int) (x, y, u, v int) {
+	n, m := aHi-aLo, bHi-bLo
+	delta := n - m
+	odd := delta%2 != 0
+	maxD := (n + m + 1) / 2
+	offset := maxD + 1
+	// forward[offset+k] is the furthest x on the diagonal k = x - y searching forward,
+	// and backward[offset+k] is the furthest distance from the end on the diagonal k searching backward,
+	// which is the diagonal delta - k of the forward search.
+	forward, backward := make([]int, 2*maxD+3), make([]int, 2*maxD+3)
+	for edits := 0; edits <= maxD; edits++ {
+		for k := -edits; k <= edits; k += 2 {
+			var x int
+			if k == -edits || (k != edits && forward[offset+k-1] < forward[offset+k+1]) {
+				x = forward[offset+k+1]
+			} else {
+				x = forward[offset+k-1] + 1
+			}
+			y := x - k
+			startX, startY := x, y
+			for x < n && y < m && d.a[aLo+x] == d.b[bLo+y] {
+				x, y = x+1, y+1
+			}
+			forward[offset+k] = x
+			if reverse := delta - k; odd && reverse >= -(edits-1) && reverse <= edits-1 && x+backward[offset+reverse] >= n {
+				return aLo + startX, bLo + startY, aLo + x, bLo + y
+			}
+		}
+		for k := -edits; k <= edits; k += 2 {
+			var x int
+			if k == -edits || (k != edits && backward[offset+k-1] < backward[offset+k+1]) {
+				x = backward[offset+k+1]
+			} else {
+				x = backward[offset+k-1] + 1
+			}
+			y := x - k
+			startX, startY := x, y
+			for x < n && y < m && d.a[aHi-1-x] == d.b[bHi-1-y] {
+				x, y = x+1, y+1
+			}
+			backward[offset+k] = x
+			if reverse := delta - k; !odd && reverse >= -edits && reverse <= edits && x+forward[offset+reverse] >= n {
+				return aHi - x, bHi - y, aHi - startX, bHi - startY
+			}
+		}
+	}
+	// unreachable, the paths overlap within maxD edits
+	return aLo, bLo, aLo, bLo
+}
+
+// hunks groups the edit script into hunks with context lines.
+func hunks(ops []op) []string {
+	var result []string
+	for start := 0; start < len(ops); {
+		// find the next change
+		first := start
+		for first < len(ops) && ops[first].kind == opEqual {
+			first++
+		}
+		if first == len(ops) {
+			break
+		}
+		// extend the hunk while the changes are close enough
+		last := first
+		for i := first; i < len(ops); i++ {
+			if ops[i].kind != opEqual {
+				last = i
+			} else if i-last > 2*contextLines {
+				break
+			}
+		}
+		from := max(first-contextLines, start)
+		to := min(last+contextLines+1, len(ops))
+
+		oldLine, newLine := 1, 1
+		for _, o := range ops[:from] {
+			if o.kind != opInsert {
+				oldLine++
+			}
+			if o.kind != opDelete {
+				newLine++
+			}
+		}
+		var oldCount, newCount int
+		var body strings.Builder
+		for _, o := range ops[from:to] {
+			if o.kind != opInsert {
+				oldCount++
+			}
+			if o.kind != opDelete {
+				newCount++
+			}
+			body.WriteByte(byte(o.kind))
+			body.WriteString(o.text)
+			if !strings.HasSuffix(o.text, "\n") {
+				body.WriteString("\n\\ No newline at end of file\n")
+			}
+		}
+		result = append(result, fmt.Sprintf("@@ -%s +%s @@\n%s", hunkRange(oldLine, oldCount), hunkRange(newLine, newCount), body.String()))
+		start = to
+	}
+	return result
+}
+
+func hunkRange(line, count int) string {
+	if count == 0 {
+		// an empty range refers to the line before it
+		line--
+	}
+	if count == 1 {
+		return fmt.Sprintf("%d", line)
+	}
+	return fmt.Sprintf("%d,%d", line, count)
+}
@@ -0,0 +1,141 @@
+package diff
+
+import (
+	"math/rand/v2"
+	"runtime"
+	"slices"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func TestUnified_Equal(t *testing.T) {
+	if result := Unified("a", "b", "x\ny\n", "x\ny\n"); result != "" {
+		t.Errorf("expected empty diff, got %q", result)
+	}
+}
+
+func TestUnified_Change(t *testing.T) {
+	oldText := "package main\n\nfunc a() {}\n\nfunc b() {}\n"
+	newText := "package main\n\nfunc a() {}\n\nfunc c() {}\n"
+	expected := "--- a.go\n+++ a.go (generated)\n" +
+		"@@ -2,4 +2,4 @@\n" +
+		" \n" +
+		" func a() {}\n" +
+		" \n" +
+		"-func b() {}\n" +
+		"+func c() {}\n"
+	if result := Unified("a.go", "a.go (generated)", oldText, newText); result != expected {
+		t.Errorf("expected:\n%s\ngot:\n%s", expected, result)
+	}
+}
+
+func TestUnified_Hunks(t *testing.T) {
+	var oldLines, newLines []string
+	for i := 0; i < 20; i++ {
+		line := string(rune('a' + i))
+		oldLines = append(oldLines, line)
+		switch i {
+		case 1:
+			newLines = append(newLines, "B")
+		case 15:
+			// deleted
+		default:
+			newLines = append(newLines, line)
+		}
+	}
+	newLines = append(newLines, "z")
+	result := Unified("old", "new", strings.Join(oldLines, "\n")+"\n", strings.Join(newLines, "\n")+"\n")
+	expected := "--- old\n+++ new\n" +
+		"@@ -1,5 +1,5 @@\n a\n-b\n+B\n c\n d\n e\n" +
+		"@@ -13,8 +13,8 @@\n m\n n\n o\n-p\n q\n r\n s\n t\n+z\n"
+	if result != expected {
+		t.Errorf("expected:\n%s\ngot:\n%s", expected, result)
+	}
+}
+
+func TestUnified_Empty(t *testing.T) {
+	expected := "--- old\n+++ new\n@@ -0,0 +1,2 @@\n+a\n+b\n"
+	if result := Unified("old", "new", "", "a\nb\n"); result != expected {
+		t.Errorf("expected:\n%s\ngot:\n%s", expected, result)
+	}
+}
+
+func TestLines_Shortest(t *testing.T) {
+	rng := rand.New(rand.NewPCG(1, 2))
+	random := func() []string {
+		s := make([]string, rng.IntN(12))
+		for i := range s {
+			s[i] = string(rune('a' + rng.IntN(3)))
+		}
+		return s
+	}
+	for range 500 {
+		a, b := random(), random()
+		var oldLines, newLines []string
+		edits := 0
+		for _, o := range lines(a, b) {
+			if o.kind != opInsert {
+				oldLines = append(oldLines, o.text)
+			}
+			if o.kind != opDelete {
+				newLines = append(newLines, o.text)
+			}
+			if o.kind != opEqual {
+				edits++
+			}
+		}
+		if !slices.Equal(oldLines, a) || !slices.Equal(newLines, b) {
+			t.Fatalf("edit script of %q to %q does not apply", a, b)
+		}
+		if expected := len(a) + len(b) - 2*lcs(a, b); edits != expected {
+			t.Fatalf("edit script of %q to %q has %d edits, expected %d", a, b, edits, expected)
+		}
+	}
+}
+
+// lcs returns the length of the longest common subsequence of a and b.
+func lcs(a, b []string) int {
+	prev, cur := make([]int, len(b)+1), make([]int, len(b)+1)
+	for i := range a {
+		for j := range b {
+			if a[i] == b[j] {
+				cur[j+1] = prev[j] + 1
+			} else {
+				cur[j+1] = max(prev[j+1], cur[j])
+			}
+		}
+		prev, cur = cur, prev
+	}
+	return prev[len(b)]
+}
+
+func TestLines_Memory(t *testing.T) {
+	var oldLines, newLines []string
+	for i := range 4000 {
+		oldLines = append(oldLines, "old "+strconv.Itoa(i))
+		newLines = append(newLines, "new "+strconv.Itoa(i))
+	}
+	tests := []struct {
+		name string
+		a, b []string
+	}{
+		{"empty old", nil, newLines},
+		{"empty new", oldLines, nil},
+		{"rewritten", oldLines, newLines},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var before, after runtime.MemStats
+			runtime.ReadMemStats(&before)
+			ops := lines(tt.a, tt.b)
+			runtime.ReadMemStats(&after)
+			if len(ops) != len(tt.a)+len(tt.b) {
+				t.Errorf("expected %d edits, got %d", len(tt.a)+len(tt.b), len(ops))
+			}
+			if allocated := after.TotalAlloc - before.TotalAlloc; allocated > 16<<20 {
+				t.Errorf("expected linear memory, allocated %d bytes", allocated)
+			}
+		})
+	}
+}
@@ -0,0 +1,343 @@
+package mapper
+
+import (
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"net/url"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+)
+
+// Statement is a sql statement declared in a mapper file.
+type Statement struct {
+	// Action is the tag name of the statement, e.g. select, insert, update or delete.
+	Action string
+	// SQL is the raw inner xml of the statement, dynamic tags included.
+	SQL    string
+	attrs  map[string]string
+	mapper *Mapper
+}
+
+// ID returns the id attribute of the statement.
+func (s *Statement) ID() string {
+	return s.attrs["id"]
+}
+
+// Name returns the full name of the statement, which is the namespace of its mapper plus its id.
+func (s *Statement) Name() string {
+	return s.mapper.Namespace + "." + s.ID()
+}
+
+// Attribute returns the value of the attribute with the given key.
+// Like juice, it falls back to the attribute of the mapper if the statement does not declare it.
+func (s *Statement) Attribute(key string) string {
+	if value := s.attrs[key]; value != "" {
+		return value
+	}
+	return s.mapper.attrs[key]
+}
+
+// conditionalElements are the elements whose content may not be rendered.
+var conditionalElements = map[string]bool{"if": true, "choose": true, "when": true, "otherwise": true}
+
+// ForeachCollections returns the collections of the foreach elements of the statement,
+// which are always rendered since they are not nested in conditional elements.
+func (s *Statement) ForeachCollections() ([]string, error) {
+	decoder := xml.NewDecoder(strings.NewReader("<statement>" + s.SQL + "</statement>"))
+	var (
+		collections []string
+		stack       []string
+		conditional int
+	)
+	for {
+		token, err := decoder.Token()
+		if errors.Is(err, io.EOF) {
+			return collections, nil
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse statement %q: %w", s.Name(), err)
+		}
+		switch token := token.(type) {
+		case xml.StartElement:
+			stack = append(stack, token.Name.Local)
+			if conditionalElements[token.Name.Local] {
+				conditional++
+			}
+			if token.Name.Local == "foreach" && conditional == 0 {
+				if collection := attribute(token, "collection"); collection != "" {
+					collections = append(collections, collection)
+				}
+			}
+		case xml.EndElement:
+			if name := stack[len(stack)-1]; conditionalElements[name] {
+				conditional--
+			}
+			stack = stack[:len(stack)-1]
+		}
+	}
+}
+
+// Mapper is a set of statements declared under the same namespace.
+type Mapper struct {
+	// Namespace is the full namespace of the mapper, with the mappers prefix applied.
+	Namespace  string
+	Statements []*Statement
+	attrs      map[string]string
+}
+
+// Statement returns the statement with the given id.
+func (m *Mapper) Statement(id string) (*Statement, bool) {
+	for _, statement := range m.Statements {
+		if statement.ID() == id {
+			return statement, true
+		}
+	}
+	return nil, false
+}
+
+// Index is an index of all mappers declared by a juice configuration file.
+// Unlike juice.Configuration, it keeps the metadata of the mappers and statements,
+// so that the generator can enumerate them.
+type Index struct {
+	Mappers []*Mapper
+}
+
+// Mapper returns the mapper with the given namespace.
+func (i *Index) Mapper(namespace string) (*Mapper, bool) {
+	for _, mapper := range i.Mappers {
+		if mapper.Namespace == namespace {
+			return mapper, true
+		}
+	}
+	return nil, false
+}
+
+// Statement returns the statement with the given full name, which is the namespace of its mapper and its id.
+func (i *Index) Statement(name string) (*Statement, bool) {
+	dot := strings.LastIndex(name, ".")
+	if dot < 0 {
+		return nil, false
+	}
+	m, ok := i.Mapper(name[:dot])
+	if !ok {
+		return nil, false
+	}
+	return m.Statement(name[dot+1:])
+}
+
+// defaultConfigFiles are the configuration files searched in order when the configuration file is not specified.
+var defaultConfigFiles = [...]string{
+	"juice.xml",
+	"config/juice.xml",
+	"config.xml",
+	"config/config.xml",
+}
+
+// DefaultConfigFiles returns the configuration files searched in order when the configuration file is not specified.
+func DefaultConfigFiles() []string {
+	return slices.Clone(defaultConfigFiles[:])
+}
+
+// FindConfig returns the configuration file, which is the given one if specified,
+// otherwise the first of the default configuration files which exists in the current directory.
+func FindConfig(filename string) (string, error) {
+	if filename != "" {
+		return filename, nil
+	}
+	for _, defaultConfigFile := range defaultConfigFiles {
+		_, err := os.Stat(defaultConfigFile)
+		if err == nil {
+			return defaultConfigFile, nil
+		}
+		if !errors.Is(err, fs.ErrNotExist) {
+			return "", err
+		}
+	}
+	return "", errors.New(strings.Join(defaultConfigFiles[:], "|") + " not found")
+}
+
+// Load reads the configuration file and all the mapper files it references.
+// Mappers loaded by http urls are skipped, since they can not be resolved locally.
+func Load(filename string) (*Index, error) {
+	root, err := os.OpenRoot(filepath.Dir(filename))
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = root.Close() }()
+	loader := &loader{fs: root.FS()}
+	if err = loader.loadConfiguration(filepath.Base(filename)); err != nil {
+		return nil, err
+	}
+	return &Index{Mappers: loader.mappers}, nil
+}
+
+type loader struct {
+	fs      fs.FS
+	prefix  string
+	mappers []*Mapper
+}
+
+func (l *loader) loadConfiguration(name string) error {
+	file, err := l.fs.Open(name)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = file.Close() }()
+	decoder := xml.NewDecoder(file)
+	for {
+		token, err := decoder.Token()
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if start, ok := token.(xml.StartElement); ok && start.Name.Local == "mappers" {
+			if err = l.loadMappers(decoder, start); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+func (l *loader) loadMappers(decoder *xml.Decoder, start xml.StartElement) error {
+	l.prefix = attribute(start, "prefix")
+	if pattern := attribute(start, "pattern"); pattern != "" {
+		matches, err := fs.Glob(l.fs, pattern)
+		if err != nil {
+			return fmt.Errorf("invalid pattern %q: %w", pattern, err)
+		}
+		for _, match := range matches {
+			if err = l.loadResource(match); err != nil {
+				return err
+			}
+		}
+	}
+	for {
+		token, err := decoder.Token()
+		if err != nil {
+			return err
+		}
+		switch token := token.(type) {
+		case xml.StartElement:
+			if token.Name.Local != "mapper" {
+				continue
+			}
+			if err = l.loadMapper(decoder, token); err != nil {
+				return err
+			}
+		case xml.EndElement:
+			if token.Name.Local == "mappers" {
+				return nil
+			}
+		}
+	}
+}
+
+func (l *loader) loadMapper(decoder *xml.Decoder, start xml.StartElement) error {
+	if resource := attribute(start, "resource"); resource != "" {
+		if err := decoder.Skip(); err != nil {
+			return err
+		}
+		return l.loadResource(resource)
+	}
+	if rawURL := attribute(start, "url"); rawURL != "" {
+		if err := decoder.Skip(); err != nil {
+			return err
+		}
+		u, err := url.Parse(rawURL)
+		if err != nil {
+			return err
+		}
+		if u.Scheme != "file" {
+			return nil
+		}
+		return l.loadResource(u.Path)
+	}
+	return l.parseMapper(decoder, start)
+}
+
+func (l *loader) loadResource(name string) error {
+	file, err := l.fs.Open(name)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = file.Close() }()
+	decoder := xml.NewDecoder(file)
+	for {
+		token, err := decoder.Token()
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("failed to parse mapper %q: %w", name, err)
+		}
+		if start, ok := token.(xml.StartElement); ok && start.Name.Local == "mapper" {
+			return l.parseMapper(decoder, start)
+		}
+	}
+}
+
+// xmlStatement is used to decode a statement element.
+type xmlStatement struct {
+	Attrs []xml.Attr `xml:",any,attr"`
+	Inner string     `xml:",innerxml"`
+}
+
+func (l *loader) parseMapper(decoder *xml.Decoder, start xml.StartElement) error {
+	namespace := attribute(start, "namespace")
+	if namespace == "" {
+		return errors.New("mapper namespace is required")
+	}
+	if l.prefix != "" {
+		namespace = l.prefix + "." + namespace
+	}
+	mapper := &Mapper{Namespace: namespace, attrs: make(map[string]string)}
+	for _, attr := range start.Attr {
+		mapper.attrs[attr.Name.Local] = attr.Value
+	}
+	for {
+		token, err := decoder.Token()
+		if err != nil {
+			return err
+		}
+		switch token := token.(type) {
+		case xml.StartElement:
+			switch token.Name.Local {
+			case "select", "insert", "update", "delete":
+				var element xmlStatement
+				if err = decoder.DecodeElement(&element, &token); err != nil {
+					return err
+				}
+				statement := &Statement{Action: token.Name.Local, SQL: element.Inner, attrs: make(map[string]string), mapper: mapper}
+				for _, attr := range element.Attrs {
+					statement.attrs[attr.Name.Local] = attr.Value
+				}
+				mapper.Statements = append(mapper.Statements, statement)
+			default:
+				if err = decoder.Skip(); err != nil {
+					return err
+				}
+			}
+		case xml.EndElement:
+			if token.Name.Local == "mapper" {
+				l.mappers = append(l.mappers, mapper)
+				return nil
+			}
+		}
+	}
+}
+
+func attribute(element xml.StartElement, key string) string {
+	for _, attr := range element.Attr {
+		if attr.Name.Local == key {
+			return attr.Value
+		}
+	}
+	return ""
+}
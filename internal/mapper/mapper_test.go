@@ -0,0 +1,152 @@
+package mapper
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestStatementForeachCollections(t *testing.T) {
+	statement := &Statement{
+		SQL: `select * from user where id in <foreach collection="ids" item="id" open="(" separator="," close=")">#{id}</foreach>
+		<if test="len(names) > 0">and name in <foreach collection="names" item="name" open="(" separator="," close=")">#{name}</foreach></if>
+		<where><choose><when test="tags != nil"><foreach collection="tags" item="tag">#{tag}</foreach></when></choose></where>
+		and age &lt; 18 and status in <foreach collection="statuses" item="status" open="(" separator="," close=")">#{status}</foreach>`,
+		attrs:  map[string]string{"id": "List"},
+		mapper: &Mapper{Namespace: "repo.UserRepo"},
+	}
+	collections, err := statement.ForeachCollections()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expected := []string{"ids", "statuses"}
+	if !reflect.DeepEqual(collections, expected) {
+		t.Errorf("expected %v, got %v", expected, collections)
+	}
+}
+
+func TestFindConfig(t *testing.T) {
+	t.Chdir(t.TempDir())
+	if _, err := FindConfig(""); err == nil {
+		t.Error("expected error when no configuration file exists")
+	}
+	if err := os.MkdirAll("config", 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join("config", "juice.xml"), []byte("<configuration/>"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	for filename, expected := range map[string]string{"": filepath.Join("config", "juice.xml"), "custom.xml": "custom.xml"} {
+		found, err := FindConfig(filename)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if found != expected {
+			t.Errorf("expected %s, got %s", expected, found)
+		}
+	}
+}
+
+func TestLoad(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"juice.xml": `<?xml version="1.0" encoding="UTF-8"?>
+<configuration>
+    <environments default="prod">
+        <environment id="prod"/>
+    </environments>
+    <mappers prefix="app" pattern="mappers/*.xml">
+        <mapper resource="user.xml"/>
+        <mapper resource="order.xml"/>
+        <mapper url="http://example.com/remote.xml"/>
+        <mapper namespace="inline">
+            <select id="Ping">select 1</select>
+        </mapper>
+    </mappers>
+</configuration>`,
+		"user.xml": `<?xml version="1.0" encoding="utf-8" ?>
+<mapper namespace="repo.UserRepository" timeout="3s">
+    <resultMap id="userMap"/>
+    <select id="GetByID" timeout="1s">select * from user where id = #{id}</select>
+    <insert id="Create">insert into user (name) values (#{name})</insert>
+</mapper>`,
+		"order.xml":         `<mapper namespace="repo.OrderRepository"><delete id="Cancel">delete from orders</delete></mapper>`,
+		"mappers/post.xml":  `<mapper namespace="repo.PostRepository"><update id="Publish">update post set published = true</update></mapper>`,
+		"mappers/other.txt": `not a mapper`,
+	}
+	for name, content := range files {
+		if err := os.MkdirAll(filepath.Join(dir, filepath.Dir(name)), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	index, err := Load(filepath.Join(dir, "juice.xml"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	var namespaces []string
+	for _, m := range index.Mappers {
+		namespaces = append(namespaces, m.Namespace)
+	}
+	expected := []string{"app.repo.PostRepository", "app.repo.UserRepository", "app.repo.OrderRepository", "app.inline"}
+	if !reflect.DeepEqual(namespaces, expected) {
+		t.Fatalf("expected mappers %v, got %v", expected, namespaces)
+	}
+	m, ok := index.Mapper("app.repo.UserRepository")
+	if !ok {
+		t.Fatal("expected mapper app.repo.UserRepository")
+	}
+	if len(m.Statements) != 2 {
+		t.Fatalf("expected 2 statements, got %d", len(m.Statements))
+	}
+	statement, ok := m.Statement("GetByID")
+	if !ok {
+		t.Fatal("expected statement GetByID")
+	}
+	if statement.Name() != "app.repo.UserRepository.GetByID" || statement.Action != "select" || statement.SQL != "select * from user where id = #{id}" {
+		t.Errorf("unexpected statement %s %s: %s", statement.Action, statement.Name(), statement.SQL)
+	}
+	if timeout := statement.Attribute("timeout"); timeout != "1s" {
+		t.Errorf("expected the timeout of the statement, got %q", timeout)
+	}
+	create, _ := m.Statement("Create")
+	if timeout := create.Attribute("timeout"); timeout != "3s" {
+		t.Errorf("expected the timeout of the mapper, got %q", timeout)
+	}
+	if _, ok = m.Statement("userMap"); ok {
+		t.Error("expected the result map not to be a statement")
+	}
+	if found, ok := index.Statement("app.repo.UserRepository.GetByID"); !ok || found != statement {
+		t.Error("expected the statement by its full name")
+	}
+	for _, name := range []string{"app.repo.UserRepository.Missing", "app.repo.Missing.GetByID", "GetByID"} {
+		if _, ok = index.Statement(name); ok {
+			t.Errorf("expected no statement %s", name)
+		}
+	}
+}
+
+func TestLoad_Errors(t *testing.T) {
+	tests := map[string]string{
+		"missing mapper file": `<configuration><mappers><mapper resource="missing.xml"/></mappers></configuration>`,
+		"missing namespace":   `<configuration><mappers><mapper><select id="Ping">select 1</select></mapper></mappers></configuration>`,
+		"invalid xml":         `<configuration><mappers><mapper namespace="a"><select id="Ping"></mapper></mappers></configuration>`,
+	}
+	for name, content := range tests {
+		t.Run(name, func(t *testing.T) {
+			filename := filepath.Join(t.TempDir(), "juice.xml")
+			if err := os.WriteFile(filename, []byte(content), 0o644); err != nil {
+				t.Fatal(err)
+			}
+			if _, err := Load(filename); err == nil {
+				t.Error("expected error")
+			}
+		})
+	}
+	if _, err := Load(filepath.Join(t.TempDir(), "juice.xml")); err == nil {
+		t.Error("expected error when the configuration file does not exist")
+	}
+}
@@ -2,9 +2,12 @@ package module
 
 import (
 	"bufio"
+	"bytes"
 	"errors"
+	"fmt"
 	"io"
 	"os"
+	"os/exec"
 	"path/filepath"
 	"strings"
 )
@@ -33,6 +36,22 @@ func ParseGoModuleName(f io.Reader) (string, error) {
 	return module, nil
 }
 
+// GoVersion returns the version of the go directive of the module which contains the given directory, e.g. 1.23,
+// or empty if go.mod does not declare it.
+func GoVersion(dir string) (string, error) {
+	cmd := exec.Command("go", "list", "-m", "-f", "{{.GoVersion}}")
+	cmd.Dir = dir
+	// the module which contains the directory, rather than the modules of the workspace
+	cmd.Env = append(os.Environ(), "GOWORK=off")
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	output, err := cmd.Output()
+	if err != nil {
+		return "", fmt.Errorf("go list: %w: %s", err, bytes.TrimSpace(stderr.Bytes()))
+	}
+	return strings.TrimSpace(string(output)), nil
+}
+
 // FindGoModPath go.mod file and return path of go.mod
 func FindGoModPath(path string) (string, error) {
 	var goModPath = path
@@ -49,6 +68,36 @@ func FindGoModPath(path string) (string, error) {
 	return goModPath, nil
 }
 
+// ImportPath returns the import path of the package in the given directory,
+// which is the module name plus the path of the directory relative to go.mod.
+func ImportPath(dir string) (string, error) {
+	dir, err := filepath.Abs(dir)
+	if err != nil {
+		return "", err
+	}
+	goModPath, err := FindGoModPath(dir)
+	if err != nil {
+		return "", err
+	}
+	f, err := os.Open(filepath.Join(goModPath, "go.mod"))
+	if err != nil {
+		return "", err
+	}
+	defer func() { _ = f.Close() }()
+	module, err := ParseGoModuleName(f)
+	if err != nil {
+		return "", err
+	}
+	relativePath, err := filepath.Rel(goModPath, dir)
+	if err != nil {
+		return "", err
+	}
+	if relativePath == "." {
+		return module, nil
+	}
+	return module + "/" + filepath.ToSlash(relativePath), nil
+}
+
 func fileExists(path string) (bool, error) {
 	_, err := os.Stat(path)
 	if err == nil {
@@ -83,3 +83,43 @@ func TestGetPackageName_MultipleGoFiles(t *testing.T) {
 		t.Errorf("expected package name 'main', got '%s'", packageName)
 	}
 }
+
+func TestImportPath(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "go.mod"), []byte("module example.com/app\n"), 0o644); err != nil {
+		t.Fatalf("failed to write go.mod: %v", err)
+	}
+	if err := os.MkdirAll(filepath.Join(dir, "domain", "user"), 0o755); err != nil {
+		t.Fatalf("failed to create dir: %v", err)
+	}
+
+	for dir, expected := range map[string]string{
+		dir:                                  "example.com/app",
+		filepath.Join(dir, "domain", "user"): "example.com/app/domain/user",
+	} {
+		importPath, err := ImportPath(dir)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if importPath != expected {
+			t.Errorf("expected import path '%s', got '%s'", expected, importPath)
+		}
+	}
+}
+
+func TestGoVersion(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "go.mod"), []byte("module example.com/app\n\ngo 1.23.0 // toolchain floor\n"), 0o644); err != nil {
+		t.Fatalf("failed to write go.mod: %v", err)
+	}
+	if err := os.MkdirAll(filepath.Join(dir, "repo"), 0o755); err != nil {
+		t.Fatalf("failed to create dir: %v", err)
+	}
+	version, err := GoVersion(filepath.Join(dir, "repo"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if version != "1.23.0" {
+		t.Errorf("expected go version '1.23.0', got '%s'", version)
+	}
+}
@@ -0,0 +1,80 @@
+package module
+
+import (
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// ResolvePackageDirs returns the directories matched by the given package patterns.
+// A pattern is a directory path, optionally ending with "/..." to match the directory
+// and all of its subdirectories which contain go files.
+// Like the go command, directories named testdata or vendor and those beginning with "." or "_" are ignored.
+func ResolvePackageDirs(patterns ...string) ([]string, error) {
+	var dirs []string
+	seen := make(map[string]struct{})
+	add := func(dir string) {
+		dir = filepath.Clean(dir)
+		if _, ok := seen[dir]; ok {
+			return
+		}
+		seen[dir] = struct{}{}
+		dirs = append(dirs, dir)
+	}
+	for _, pattern := range patterns {
+		root, recursive := strings.CutSuffix(filepath.ToSlash(pattern), "/...")
+		if root == "..." {
+			root, recursive = ".", true
+		}
+		if !recursive {
+			add(root)
+			continue
+		}
+		err := filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+			if err != nil {
+				return err
+			}
+			if !d.IsDir() {
+				return nil
+			}
+			if path != root && ignoredDir(d.Name()) {
+				return filepath.SkipDir
+			}
+			ok, err := hasGoFiles(path)
+			if err != nil {
+				return err
+			}
+			if ok {
+				add(path)
+			}
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+	return dirs, nil
+}
+
+func ignoredDir(name string) bool {
+	return name == "testdata" || name == "vendor" || strings.HasPrefix(name, ".") || strings.HasPrefix(name, "_")
+}
+
+func hasGoFiles(dir string) (bool, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return false, err
+	}
+	for _, entry := range entries {
+		if !entry.IsDir() && isGoSourceFile(entry.Name()) {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// isGoSourceFile reports whether the file is a go source file, test files excluded.
+func isGoSourceFile(name string) bool {
+	return strings.HasSuffix(name, ".go") && !strings.HasSuffix(name, "_test.go")
+}
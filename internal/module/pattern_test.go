@@ -0,0 +1,76 @@
+package module
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func writeGoFile(t *testing.T, path, content string) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		t.Fatalf("failed to create dir: %v", err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatalf("failed to write test file: %v", err)
+	}
+}
+
+func TestResolvePackageDirs_Recursive(t *testing.T) {
+	dir := t.TempDir()
+	writeGoFile(t, filepath.Join(dir, "main.go"), "package main\n")
+	writeGoFile(t, filepath.Join(dir, "repo", "repo.go"), "package repo\n")
+	writeGoFile(t, filepath.Join(dir, "repo", "empty", "README.md"), "")
+	writeGoFile(t, filepath.Join(dir, "repo", "testdata", "data.go"), "package testdata\n")
+	writeGoFile(t, filepath.Join(dir, "only_test", "a_test.go"), "package only\n")
+
+	dirs, err := ResolvePackageDirs(dir + "/...")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expected := []string{dir, filepath.Join(dir, "repo")}
+	if !reflect.DeepEqual(dirs, expected) {
+		t.Errorf("expected %v, got %v", expected, dirs)
+	}
+}
+
+func TestResolvePackageDirs_Uniq(t *testing.T) {
+	dir := t.TempDir()
+	writeGoFile(t, filepath.Join(dir, "repo", "repo.go"), "package repo\n")
+
+	dirs, err := ResolvePackageDirs(filepath.Join(dir, "repo"), dir+"/...")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expected := []string{filepath.Join(dir, "repo")}
+	if !reflect.DeepEqual(dirs, expected) {
+		t.Errorf("expected %v, got %v", expected, dirs)
+	}
+}
+
+func TestFindInterfaceNodes(t *testing.T) {
+	dir := t.TempDir()
+	writeGoFile(t, filepath.Join(dir, "repo.go"), `package repo
+
+type UserRepo interface{ Get() }
+
+type User struct{}
+
+type AdminRepo interface{ Get() }
+`)
+	writeGoFile(t, filepath.Join(dir, "repo_test.go"), "package repo\n\ntype MockRepo interface{}\n")
+
+	nodes, err := FindInterfaceNodes(dir)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	var names []string
+	for _, node := range nodes {
+		names = append(names, node.Name)
+	}
+	expected := []string{"AdminRepo", "UserRepo"}
+	if !reflect.DeepEqual(names, expected) {
+		t.Errorf("expected %v, got %v", expected, names)
+	}
+}
@@ -1,10 +1,13 @@
 package module
 
 import (
+	"errors"
 	"fmt"
 	"go/ast"
 	"go/parser"
 	"go/token"
+	"io/fs"
+	"sort"
 )
 
 func FindTypeNode(path, typeName string) (node ast.Node, file *ast.File, err error) {
@@ -38,3 +41,193 @@ func FindTypeNode(path, typeName string) (node ast.Node, file *ast.File, err err
 	}
 	return
 }
+
+// InterfaceNode is an interface type declared at the top level of a package.
+type InterfaceNode struct {
+	Name       string
+	Type       *ast.InterfaceType
+	TypeParams *ast.FieldList
+	File       *ast.File
+	// Doc is the doc comment of the type, nil if not commented.
+	Doc *ast.CommentGroup
+}
+
+// FindInterfaceNodes returns all top level interface types declared in the package of the given path.
+// Test files are ignored. The result is sorted by the type name.
+func FindInterfaceNodes(path string) ([]*InterfaceNode, error) {
+	filter := func(info fs.FileInfo) bool { return isGoSourceFile(info.Name()) }
+	pkgs, err := parser.ParseDir(token.NewFileSet(), path, filter, parser.ParseComments)
+	if err != nil {
+		return nil, err
+	}
+	var result []*InterfaceNode
+	for _, pkg := range pkgs {
+		for _, f := range pkg.Files {
+			for _, decl := range f.Decls {
+				gen, ok := decl.(*ast.GenDecl)
+				if !ok || gen.Tok != token.TYPE {
+					continue
+				}
+				for _, spec := range gen.Specs {
+					typeSpec := spec.(*ast.TypeSpec)
+					iface, ok := typeSpec.Type.(*ast.InterfaceType)
+					if !ok {
+						continue
+					}
+					doc := typeSpec.Doc
+					// the doc comment of an ungrouped declaration belongs to the declaration
+					if doc == nil && !gen.Lparen.IsValid() {
+						doc = gen.Doc
+					}
+					result = append(result, &InterfaceNode{Name: typeSpec.Name.Name, Type: iface, TypeParams: typeSpec.TypeParams, File: f, Doc: doc})
+				}
+			}
+		}
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].Name < result[j].Name })
+	return result, nil
+}
+
+// FindInterfaceNode returns the top level interface type with the given name declared in the package of the given path.
+func FindInterfaceNode(path, typeName string) (*InterfaceNode, error) {
+	nodes, err := FindInterfaceNodes(path)
+	if err != nil {
+		return nil, err
+	}
+	for _, node := range nodes {
+		if node.Name == typeName {
+			return node, nil
+		}
+	}
+	return nil, fmt.Errorf("interface %s not found", typeName)
+}
+
+// parseFiles parses the files of the package of the given path.
+// Test files are ignored, and a missing directory is treated as an empty package.
+func parseFiles(path string) ([]*ast.File, error) {
+	filter := func(info fs.FileInfo) bool { return isGoSourceFile(info.Name()) }
+	pkgs, err := parser.ParseDir(token.NewFileSet(), path, filter, parser.ParseComments)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var files []*ast.File
+	for _, pkg := range pkgs {
+		for _, f := range pkg.Files {
+			files = append(files, f)
+		}
+	}
+	return files, nil
+}
+
+// parseHandWrittenFiles parses the hand-written files of the package of the given path.
+// Test files and generated files are ignored, and a missing directory is treated as an empty package.
+func parseHandWrittenFiles(path string) ([]*ast.File, error) {
+	files, err := parseFiles(path)
+	if err != nil {
+		return nil, err
+	}
+	var result []*ast.File
+	for _, f := range files {
+		if !ast.IsGenerated(f) {
+			result = append(result, f)
+		}
+	}
+	return result, nil
+}
+
+// IsTypeDeclared reports whether the type is declared at the top level of the hand-written files
+// of the package of the given path.
+func IsTypeDeclared(path, typeName string) (bool, error) {
+	files, err := parseHandWrittenFiles(path)
+	if err != nil {
+		return false, err
+	}
+	for _, f := range files {
+		if isDeclared(f, token.TYPE, typeName) {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// IsValueDeclared reports whether the variable or constant is declared at the top level of the hand-written files
+// of the package of the given path.
+func IsValueDeclared(path, name string) (bool, error) {
+	files, err := parseHandWrittenFiles(path)
+	if err != nil {
+		return false, err
+	}
+	for _, f := range files {
+		if isDeclared(f, token.VAR, name) || isDeclared(f, token.CONST, name) {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// isDeclared reports whether the name is declared by the top level declarations of the file with the given token.
+func isDeclared(f *ast.File, tok token.Token, name string) bool {
+	for _, decl := range f.Decls {
+		gen, ok := decl.(*ast.GenDecl)
+		if !ok || gen.Tok != tok {
+			continue
+		}
+		for _, spec := range gen.Specs {
+			switch spec := spec.(type) {
+			case *ast.TypeSpec:
+				if spec.Name.Name == name {
+					return true
+				}
+			case *ast.ValueSpec:
+				for _, ident := range spec.Names {
+					if ident.Name == name {
+						return true
+					}
+				}
+			}
+		}
+	}
+	return false
+}
+
+// FindMethodNames returns the names of the methods of the type declared in the hand-written files
+// of the package of the given path, with either value or pointer receivers.
+func FindMethodNames(path, typeName string) ([]string, error) {
+	files, err := parseHandWrittenFiles(path)
+	if err != nil {
+		return nil, err
+	}
+	var names []string
+	for _, f := range files {
+		for _, decl := range f.Decls {
+			fn, ok := decl.(*ast.FuncDecl)
+			if !ok || fn.Recv == nil || len(fn.Recv.List) == 0 {
+				continue
+			}
+			if receiverTypeName(fn.Recv.List[0].Type) == typeName {
+				names = append(names, fn.Name.Name)
+			}
+		}
+	}
+	sort.Strings(names)
+	return names, nil
+}
+
+// receiverTypeName returns the type name of the receiver, e.g. *Repo[T] => Repo.
+func receiverTypeName(expr ast.Expr) string {
+	switch t := expr.(type) {
+	case *ast.StarExpr:
+		return receiverTypeName(t.X)
+	case *ast.IndexExpr:
+		return receiverTypeName(t.X)
+	case *ast.IndexListExpr:
+		return receiverTypeName(t.X)
+	case *ast.Ident:
+		return t.Name
+	default:
+		return ""
+	}
+}
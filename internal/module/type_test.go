@@ -0,0 +1,77 @@
+package module
+
+import (
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestFindMethodNames(t *testing.T) {
+	dir := t.TempDir()
+	writeGoFile(t, filepath.Join(dir, "repo.go"), `package repo
+
+type RepoImpl[T any] struct{}
+
+func (r RepoImpl[T]) Get() {}
+
+func (r *RepoImpl[T]) Close() error { return nil }
+
+func Close() {}
+`)
+	writeGoFile(t, filepath.Join(dir, "repo_impl.go"), `// Code generated by "juicecli impl"; DO NOT EDIT.
+
+package repo
+
+func (r RepoImpl[T]) List() {}
+`)
+
+	names, err := FindMethodNames(dir, "RepoImpl")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expected := []string{"Close", "Get"}
+	if !reflect.DeepEqual(names, expected) {
+		t.Errorf("expected %v, got %v", expected, names)
+	}
+}
+
+func TestIsTypeDeclared(t *testing.T) {
+	dir := t.TempDir()
+	writeGoFile(t, filepath.Join(dir, "repo.go"), "package repo\n\ntype repoCustom struct{}\n\nvar repo int\n")
+
+	for name, expected := range map[string]bool{"repoCustom": true, "repo": false, "other": false} {
+		declared, err := IsTypeDeclared(dir, name)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if declared != expected {
+			t.Errorf("%s: expected %v, got %v", name, expected, declared)
+		}
+	}
+
+	declared, err := IsTypeDeclared(filepath.Join(dir, "missing"), "repoCustom")
+	if err != nil || declared {
+		t.Errorf("expected missing package to declare nothing, got %v, %v", declared, err)
+	}
+}
+
+func TestIsValueDeclared(t *testing.T) {
+	dir := t.TempDir()
+	writeGoFile(t, filepath.Join(dir, "juice_errors_impl.go"), `// Code generated by "juicecli impl"; DO NOT EDIT.
+
+package repo
+
+var ErrUnexpectedRowCount error
+`)
+	writeGoFile(t, filepath.Join(dir, "errors.go"), "package repo\n\nconst ErrUserNotFound = \"user not found\"\n")
+
+	for name, expected := range map[string]bool{"ErrUnexpectedRowCount": false, "ErrUserNotFound": true, "ErrMissing": false} {
+		declared, err := IsValueDeclared(dir, name)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if declared != expected {
+			t.Errorf("%s: expected %v, got %v", name, expected, declared)
+		}
+	}
+}
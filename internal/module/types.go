@@ -0,0 +1,102 @@
+package module
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"go/ast"
+	"go/importer"
+	"go/parser"
+	"go/token"
+	"go/types"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+)
+
+// LoadTypes type-checks the package of the given path with full type information.
+// The files of the package are the ones listed by `go list`, which excludes the test files and the external test package,
+// and the imported packages are loaded from their export data built by `go list -export`,
+// which is much faster than type-checking them from source.
+// The type errors of the package itself are ignored, e.g. those of a stale generated file,
+// since the declarations are still resolved.
+func LoadTypes(path string) (*types.Package, error) {
+	pkgs, err := listPackages(path)
+	if err != nil {
+		return nil, err
+	}
+	exports := make(map[string]string)
+	var target *listedPackage
+	for _, pkg := range pkgs {
+		if pkg.Export != "" {
+			exports[pkg.ImportPath] = pkg.Export
+		}
+		if !pkg.DepOnly {
+			target = pkg
+		}
+	}
+	if target == nil || target.Name == "" {
+		return nil, errors.New("can not find package name")
+	}
+	fset := token.NewFileSet()
+	files := make([]*ast.File, 0, len(target.GoFiles)+len(target.CgoFiles))
+	for _, name := range append(target.GoFiles, target.CgoFiles...) {
+		f, err := parser.ParseFile(fset, filepath.Join(target.Dir, name), nil, parser.SkipObjectResolution)
+		if err != nil {
+			return nil, err
+		}
+		files = append(files, f)
+	}
+	lookup := func(importPath string) (io.ReadCloser, error) {
+		export, ok := exports[importPath]
+		if !ok {
+			return nil, fmt.Errorf("can not find export data of %s", importPath)
+		}
+		return os.Open(export)
+	}
+	config := types.Config{
+		Importer: importer.ForCompiler(fset, "gc", lookup),
+		Error:    func(error) {},
+	}
+	checked, _ := config.Check(target.ImportPath, fset, files, nil)
+	return checked, nil
+}
+
+// listedPackage is a package listed by `go list`.
+type listedPackage struct {
+	ImportPath string
+	Name       string
+	Dir        string
+	GoFiles    []string
+	CgoFiles   []string
+	// Export is the file of the export data of the package, empty if not built.
+	Export string
+	// DepOnly reports whether the package is only a dependency of the listed package.
+	DepOnly bool
+}
+
+// listPackages returns the package of the given path and its dependencies, with their export data.
+func listPackages(path string) ([]*listedPackage, error) {
+	cmd := exec.Command("go", "list", "-e", "-export", "-deps", "-json=ImportPath,Name,Dir,GoFiles,CgoFiles,Export,DepOnly", ".")
+	cmd.Dir = path
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	output, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("go list: %w: %s", err, bytes.TrimSpace(stderr.Bytes()))
+	}
+	var pkgs []*listedPackage
+	decoder := json.NewDecoder(bytes.NewReader(output))
+	for {
+		pkg := new(listedPackage)
+		if err = decoder.Decode(pkg); errors.Is(err, io.EOF) {
+			return pkgs, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		pkgs = append(pkgs, pkg)
+	}
+}
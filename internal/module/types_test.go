@@ -0,0 +1,72 @@
+package module
+
+import (
+	"go/types"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestLoadTypes(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"go.mod": "module example.com/repo\n\ngo 1.21\n",
+		"repo.go": `package repo
+
+import stdctx "context"
+
+type Ctx = stdctx.Context
+
+type Users []string
+
+type UserRepo interface {
+	List(ctx Ctx) (Users, error)
+}
+`,
+		// the type errors of a stale generated file are ignored
+		"repo_impl.go": "package repo\n\nvar _ UserRepo = Missing{}\n",
+		// the external test package and the files excluded by build constraints are not the package
+		"repo_test.go":   "package repo_test\n\ntype UserRepo int\n",
+		"repo_ignore.go": "//go:build ignore\n\npackage main\n\ntype UserRepo int\n",
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+			t.Fatalf("failed to write %s: %v", name, err)
+		}
+	}
+	pkg, err := LoadTypes(dir)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if pkg.Path() != "example.com/repo" {
+		t.Errorf("expected package path 'example.com/repo', got '%s'", pkg.Path())
+	}
+	object := pkg.Scope().Lookup("UserRepo")
+	if object == nil {
+		t.Fatal("expected UserRepo to be declared")
+	}
+	iface, ok := object.Type().Underlying().(*types.Interface)
+	if !ok || iface.NumMethods() != 1 {
+		t.Fatalf("expected UserRepo to be an interface with 1 method, got %v", object.Type().Underlying())
+	}
+	signature := iface.Method(0).Type().(*types.Signature)
+	ctx, ok := types.Unalias(signature.Params().At(0).Type()).(*types.Named)
+	if !ok || ctx.Obj().Pkg().Path() != "context" || ctx.Obj().Name() != "Context" {
+		t.Errorf("expected the param to be resolved to context.Context, got %v", signature.Params().At(0).Type())
+	}
+	if _, ok = signature.Results().At(0).Type().Underlying().(*types.Slice); !ok {
+		t.Errorf("expected the result to be resolved to a slice, got %v", signature.Results().At(0).Type())
+	}
+}
+
+func TestLoadTypes_NoModule(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "main.go"), []byte("package main\n"), 0644); err != nil {
+		t.Fatalf("failed to write test file: %v", err)
+	}
+	t.Setenv("GO111MODULE", "on")
+	t.Setenv("GOFLAGS", "")
+	if _, err := LoadTypes(dir); err == nil {
+		t.Error("expected an error outside of a module")
+	}
+}
@@ -12,11 +12,14 @@ import (
 
 type AutoComplete struct {
 	TypeName string
-	_        struct{}
+	// Dir is the directory of the package which declares the type.
+	// If not specified, the current working directory will be used.
+	Dir string
+	_   struct{}
 }
 
 func (n AutoComplete) Autocomplete() (string, error) {
-	path, err := os.Getwd()
+	path, err := n.dir()
 	if err != nil {
 		return "", err
 	}
@@ -36,35 +39,19 @@ func (n AutoComplete) Autocomplete() (string, error) {
 	return n.autoComplete(path)
 }
 
-func (n AutoComplete) autoComplete(path string) (string, error) {
-	goModPath, err := module.FindGoModPath(path)
-	if err != nil {
-		return "", err
+func (n AutoComplete) dir() (string, error) {
+	if n.Dir == "" {
+		return os.Getwd()
 	}
-	// read go.mod and get module name
-	f, err := os.Open(filepath.Join(goModPath, "go.mod"))
-	if err != nil {
-		return "", err
-	}
-	defer func() { _ = f.Close() }()
+	return filepath.Abs(n.Dir)
+}
 
-	pkg, err := module.ParseGoModuleName(f)
-	if err != nil {
-		return "", err
-	}
-	// find package name
-	relativePath, err := filepath.Rel(goModPath, path)
+func (n AutoComplete) autoComplete(path string) (string, error) {
+	importPath, err := module.ImportPath(path)
 	if err != nil {
 		return "", err
 	}
-	if relativePath == "." {
-		relativePath = ""
-	}
-	if relativePath != "" {
-		relativePath = relativePath + "/"
-	}
-	pkgName := pkg + "/" + relativePath
-	namespace := pkgName + n.TypeName
+	namespace := importPath + "/" + n.TypeName
 	replacer := strings.NewReplacer("/", ".", "\\", ".")
 	return replacer.Replace(namespace), nil
 }
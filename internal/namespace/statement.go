@@ -0,0 +1,57 @@
+package namespace
+
+import (
+	"errors"
+
+	"github.com/go-juicedev/juicecli/internal/ast"
+)
+
+// OfInterface returns the namespace of the interface, which is declared by its //juice:namespace directive,
+// otherwise derived from its package.
+func OfInterface(iface *ast.Interface) (string, error) {
+	if ns, ok := iface.Directive("namespace"); ok {
+		return ns, nil
+	}
+	cmp := AutoComplete{TypeName: iface.Name, Dir: iface.Dir}
+	return cmp.Autocomplete()
+}
+
+// StatementID returns the id of the statement of the method,
+// which is the method name, or the one declared by its //juice:statement directive.
+func StatementID(method *ast.Function) (string, error) {
+	args, ok := method.Directive("statement")
+	if !ok {
+		return method.Name(), nil
+	}
+	if args == "" {
+		return "", errors.New("statement directive requires the statement id")
+	}
+	return args, nil
+}
+
+// Statement returns the full name of the statement of the method of the interface with the namespace ns,
+// and whether it is declared, which is reported by declared.
+// The methods of embedded interfaces are looked up under ns first,
+// then under the namespace of the embedded interface which declares them.
+// If the statement is not declared, the full name under ns is returned.
+func Statement(ns string, method *ast.Function, declared func(name string) bool) (string, bool, error) {
+	id, err := StatementID(method)
+	if err != nil {
+		return "", false, err
+	}
+	name := ns + "." + id
+	if declared(name) {
+		return name, true, nil
+	}
+	if method.Embedded == nil {
+		return name, false, nil
+	}
+	embeddedNamespace, err := OfInterface(method.Embedded)
+	if err != nil {
+		return "", false, err
+	}
+	if embeddedName := embeddedNamespace + "." + id; declared(embeddedName) {
+		return embeddedName, true, nil
+	}
+	return name, false, nil
+}